@@ -3,8 +3,13 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
 
 	"github.com/roman-mazur/architecture-lab-3/painter"
 	"github.com/roman-mazur/architecture-lab-3/painter/lang"
@@ -19,7 +24,21 @@ const (
 	HttpPort     = ":17000"
 )
 
+var (
+	unixSocket = flag.String("unix", "", "Path of a Unix domain socket to accept command lines on")
+	stdinREPL  = flag.Bool("stdin", false, "Read command lines from standard input")
+	journal    = flag.String("journal", "", "File to record all received operations to, for cmd/replay")
+	scenesDir  = flag.String("scenes", painter.ScenesDir, "Directory for scenes saved with 'save' and loaded with 'load'")
+	exportDir  = flag.String("exports", painter.ExportDir, "Directory for files written by 'export-svg'")
+	spritesDir = flag.String("sprites", painter.SpritesDir, "Directory for images uploaded to /sprites and placed with 'image'")
+	antialias  = flag.Bool("antialias", false, "Render rotated figures and shapes with anti-aliased edges")
+)
+
 func main() {
+	flag.Parse()
+	painter.ScenesDir = *scenesDir
+	painter.ExportDir = *exportDir
+	painter.SpritesDir = *spritesDir
 	log.Println("Starting Painter Application...")
 
 	// 1. Ініціалізуємо Visualizer БЕЗ Loop на цьому етапі
@@ -33,20 +52,70 @@ func main() {
 	// 2. Ініціалізуємо Painter Loop, передаючи visualizer як Receiver
 	// NewLoop повертає *painter.Loop
 	painterLoop := painter.NewLoop(visualizer, WindowWidth, WindowHeight)
+	painterLoop.Antialias = *antialias // Режим згладжування можна змінити командою 'antialias'
 
 	// 3. Встановлюємо ВКАЗІВНИК на painterLoop у visualizer
 	visualizer.Loop = painterLoop
 
+	// Журнал операцій для відтворення помилок через cmd/replay
+	if *journal != "" {
+		f, err := os.Create(*journal)
+		if err != nil {
+			log.Fatalf("Failed to create journal %s: %v", *journal, err)
+		}
+		defer f.Close()
+		painterLoop.Journal = lang.NewJournalWriter(f)
+	}
+
 	// 4. Ініціалізуємо HTTP обробник, передаючи ВКАЗІВНИК на painterLoop
-	httpHandler := lang.HttpHandler(painterLoop) // Припускаємо, що HttpHandler приймає *painter.Loop
+	mux := http.NewServeMux()
+	mux.Handle("/", lang.HttpHandler(painterLoop))
+	mux.Handle("GET /help", lang.HelpHandler(lang.Default))     // Опис доступних команд
+	mux.Handle("GET /events", lang.EventsHandler(painterLoop))  // SSE-потік змін стану
+	mux.Handle("GET /ws", lang.WebSocketHandler(painterLoop))   // Двосторонній канал команд
+	mux.Handle("GET /export.svg", lang.SVGHandler(painterLoop)) // Поточна сцена у форматі SVG
+	mux.Handle("GET /pick", lang.PickHandler(painterLoop))      // Пошук фігури під точкою
+	scenes := lang.ScenesHandler(painterLoop)                   // Збереження та завантаження сцен
+	mux.Handle("/scenes", scenes)
+	mux.Handle("/scenes/", scenes)
+	sprites := lang.SpritesHandler(painterLoop) // Завантаження зображень для команди 'image'
+	mux.Handle("/sprites", sprites)
+	mux.Handle("/sprites/", sprites)
 	go func() {
 		log.Printf("Starting HTTP server on port %s", HttpPort)
-		err := http.ListenAndServe(HttpPort, httpHandler)
+		err := http.ListenAndServe(HttpPort, mux)
 		if err != nil {
 			log.Fatalf("HTTP server failed: %v", err)
 		}
 	}()
 
+	// Додаткові транспорти команд: Unix-сокет та stdin
+	session := &lang.Session{Loop: painterLoop}
+	if *unixSocket != "" {
+		if err := removeStaleSocket(*unixSocket); err != nil {
+			log.Fatalf("Cannot use Unix socket path: %v", err)
+		}
+		ln, err := net.Listen("unix", *unixSocket)
+		if err != nil {
+			log.Fatalf("Failed to listen on Unix socket %s: %v", *unixSocket, err)
+		}
+		defer ln.Close() // Закриття Unix-лістенера також видаляє файл сокета
+		go func() {
+			log.Printf("Accepting commands on Unix socket %s", *unixSocket)
+			if err := lang.ServeListener(ln, session); err != nil {
+				log.Printf("Unix socket listener failed: %v", err)
+			}
+		}()
+	}
+	if *stdinREPL {
+		go func() {
+			repl := &lang.Session{Loop: painterLoop, Prompt: "> "}
+			if err := repl.Serve(os.Stdin, os.Stdout); err != nil {
+				log.Printf("Error reading standard input: %v", err)
+			}
+		}()
+	}
+
 	// 5. Визначаємо функцію для відкладеного запуску Loop
 	// Замикання захопить ВКАЗІВНИК painterLoop
 	visualizer.StartLoopAndRunUI = func(s screen.Screen) {
@@ -59,3 +128,19 @@ func main() {
 
 	log.Println("Painter Application Closed.")
 }
+
+// removeStaleSocket removes a socket left by a previous run at the path.
+// Other files are never removed, so a mistyped path cannot delete user data.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
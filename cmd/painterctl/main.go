@@ -0,0 +1,183 @@
+// cmd/painterctl/main.go
+
+// Command painterctl sends commands to a running painter server.
+//
+// Usage:
+//
+//	painterctl [-addr URL] [repl]        interactive mode (default)
+//	painterctl [-addr URL] send CMD...   send commands, one per argument
+//	painterctl [-addr URL] file PATH     send a script file ("-" for stdin)
+//	painterctl [-addr URL] help          list the commands known to the server
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+const defaultAddr = "http://localhost:17000"
+
+var addr = flag.String("addr", envOr("PAINTER_ADDR", defaultAddr), "Address of the painter server (or $PAINTER_ADDR)")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-addr URL] [repl | send CMD... | file PATH | help]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	client := &client{base: strings.TrimRight(*addr, "/"), http: &http.Client{Timeout: 10 * time.Second}}
+
+	args := flag.Args()
+	mode := "repl"
+	if len(args) > 0 {
+		mode, args = args[0], args[1:]
+	}
+
+	var err error
+	switch mode {
+	case "repl":
+		err = runREPL(client)
+	case "send":
+		if len(args) == 0 {
+			err = errors.New("send requires at least one command")
+			break
+		}
+		err = client.send(strings.Join(args, "\n"))
+	case "file":
+		if len(args) != 1 {
+			err = errors.New("file requires exactly one path")
+			break
+		}
+		err = sendFile(client, args[0])
+	case "help":
+		var help string
+		if help, err = client.help(); err == nil {
+			fmt.Print(help)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "painterctl:", err)
+		os.Exit(1)
+	}
+}
+
+// sendFile sends the whole script as one batch, so 'wait' commands work as in the file.
+func sendFile(c *client, path string) error {
+	var script []byte
+	var err error
+	if path == "-" {
+		script, err = io.ReadAll(os.Stdin)
+	} else {
+		script, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	return c.send(string(script))
+}
+
+// client talks to the HTTP interface of the painter server.
+type client struct {
+	base  string
+	http  *http.Client
+	names []string // Назви команд сервера, отримані з /help
+}
+
+// ServerError is returned for 4xx/5xx responses. It keeps the diagnostics
+// reported by the server, one per line.
+type ServerError struct {
+	Status string
+	Lines  []string
+}
+
+func (e *ServerError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server rejected the commands (%s)", e.Status)
+	for _, l := range e.Lines {
+		b.WriteString("\n  ")
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// send posts a script in the text format.
+func (c *client) send(script string) error {
+	resp, err := c.http.Post(c.base+"/", "text/plain", strings.NewReader(script))
+	if err != nil {
+		return fmt.Errorf("cannot reach the painter at %s: %w", c.base, err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// help fetches the list of commands known to the server.
+func (c *client) help() (string, error) {
+	resp, err := c.http.Get(c.base + "/help")
+	if err != nil {
+		return "", fmt.Errorf("cannot reach the painter at %s: %w", c.base, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// commandNames returns the names of the commands known to the server, so
+// that commands added in a newer server are not rejected locally. They are
+// fetched once from /help; if the server cannot be reached, the commands of
+// the local language are used for the rest of the session, so that a slow
+// server does not delay every completion.
+func (c *client) commandNames() []string {
+	if c.names != nil {
+		return c.names
+	}
+	help, err := c.help()
+	if err != nil {
+		c.names = lang.Default.Names()
+		return c.names
+	}
+	names := []string{}
+	for _, line := range strings.Split(help, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	c.names = names
+	return names
+}
+
+// checkResponse turns non-OK responses into a *ServerError.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	var lines []string
+	for _, l := range strings.Split(string(bytes.TrimSpace(body)), "\n") {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return &ServerError{Status: resp.Status, Lines: lines}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"golang.org/x/term"
+)
+
+const replHelp = `Commands are checked locally, unless only the server knows them, and sent to the server after 'update' or an empty line.
+Special commands: .help (server command list), .send (send pending lines), .quit`
+
+// lineReader is implemented by term.Terminal and scannerReader.
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+// scannerReader reads lines from a non-interactive input.
+type scannerReader struct{ *bufio.Scanner }
+
+func (s scannerReader) ReadLine() (string, error) {
+	if s.Scan() {
+		return s.Text(), nil
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// runREPL reads commands interactively. On a terminal it offers line editing,
+// history (up/down arrows) and tab completion; otherwise it reads plain lines.
+func runREPL(c *client) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return repl(c, scannerReader{bufio.NewScanner(os.Stdin)}, os.Stdout)
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, oldState)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "painter> ")
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, candidates := complete(line, pos, c.commandNames())
+		if len(candidates) > 1 {
+			fmt.Fprintln(t, strings.Join(candidates, "  "))
+		}
+		return newLine, newPos, newLine != line
+	}
+	fmt.Fprintln(t, replHelp)
+	return repl(c, t, t)
+}
+
+// repl runs the read-check-send loop until EOF or '.quit'.
+func repl(c *client, in lineReader, out io.Writer) error {
+	var pending []string
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := c.send(strings.Join(pending, "\n")); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		} else {
+			fmt.Fprintf(out, "sent %d command(s)\n", len(pending))
+		}
+		pending = nil
+	}
+
+	for {
+		line, err := in.ReadLine()
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == ".quit":
+			flush()
+			return nil
+		case line == ".help":
+			if help, err := c.help(); err != nil {
+				fmt.Fprintln(out, "error:", err)
+			} else {
+				fmt.Fprint(out, help)
+			}
+		case line == ".send" || line == "":
+			flush()
+		case strings.HasPrefix(line, "#"):
+		default:
+			// Перевіряємо команду локально, щоб одразу показати помилку з позицією
+			op, err := lang.Parse(line)
+			if err != nil && serverOnly(c, line) {
+				// Команду знає лише новіший сервер, тож її перевірить він
+				pending = append(pending, line)
+				continue
+			}
+			if err != nil {
+				fmt.Fprintln(out, "error:", err)
+				continue
+			}
+			pending = append(pending, line)
+			if _, ok := op.(painter.UpdateOp); ok {
+				flush()
+			}
+		}
+	}
+}
+
+// serverOnly reports whether the command of the line is known to the server
+// but not to the local language.
+func serverOnly(c *client, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	if _, ok := lang.Default.Lookup(fields[0]); ok {
+		return false
+	}
+	return slices.Contains(c.commandNames(), fields[0])
+}
+
+// complete completes the word before pos: one of the command names for the
+// first word, or one of the allowed values for an argument with fixed choices.
+// It returns the new line and cursor position, and all candidates.
+func complete(line string, pos int, names []string) (string, int, []string) {
+	head, tail := line[:pos], line[pos:]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	fields := strings.Fields(head[:start])
+
+	var options []string
+	if len(fields) == 0 {
+		options = names
+	} else if cmd, ok := lang.Default.Lookup(fields[0]); ok && len(fields)-1 < len(cmd.Args) {
+		options = cmd.Args[len(fields)-1].Choices
+	}
+
+	var candidates []string
+	for _, o := range options {
+		if strings.HasPrefix(o, word) {
+			candidates = append(candidates, o)
+		}
+	}
+	if len(candidates) == 0 {
+		return line, pos, nil
+	}
+
+	completion := candidates[0]
+	for _, c := range candidates[1:] {
+		completion = commonPrefix(completion, c)
+	}
+	if len(candidates) == 1 {
+		completion += " "
+	}
+	newHead := head[:start] + completion
+	return newHead + tail, len(newHead), candidates
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
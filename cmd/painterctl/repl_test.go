@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		line, newLine string
+		candidates    []string
+	}{
+		{"fig", "figure ", []string{"figure"}},
+		{"ani", "animate-move ", []string{"animate-move"}},
+		{"an", "an", []string{"animate-move", "antialias"}},
+		{"antialias o", "antialias o", []string{"on", "off"}},
+		{"animate-move 0.1 0.1 1s ease-", "animate-move 0.1 0.1 1s ease-", []string{"ease-in", "ease-in-out", "ease-out"}},
+		{"animate-move 0.1 0.1 1s li", "animate-move 0.1 0.1 1s linear ", []string{"linear"}},
+		{"figure 0.", "figure 0.", nil},
+		{"xyz", "xyz", nil},
+	}
+	for _, tt := range tests {
+		newLine, newPos, candidates := complete(tt.line, len(tt.line), lang.Default.Names())
+		if newLine != tt.newLine || newPos != len(tt.newLine) || !reflect.DeepEqual(candidates, tt.candidates) {
+			t.Errorf("complete(%q) = %q, %d, %v; expected %q, %v", tt.line, newLine, newPos, candidates, tt.newLine, tt.candidates)
+		}
+	}
+}
+
+func TestREPL_SendsBatchesAndReportsErrors(t *testing.T) {
+	loop := painter.NewLoop(nil, 800, 800)
+	mux := http.NewServeMux()
+	mux.Handle("/", lang.HttpHandler(loop))
+	mux.Handle("GET /help", lang.HelpHandler(lang.Default))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	c := &client{base: server.URL, http: server.Client()}
+
+	input := "white\nfigur 0.5 0.5\nfigure 0.5 0.5\nupdate\ngreen\n"
+	var out strings.Builder
+	if err := repl(c, scannerReader{bufio.NewScanner(strings.NewReader(input))}, &out); err != nil {
+		t.Fatalf("repl failed: %v", err)
+	}
+
+	expectedOut := "error: column 1: unknown command \"figur\"; did you mean \"figure\"?\nsent 3 command(s)\nsent 1 command(s)\n"
+	if out.String() != expectedOut {
+		t.Errorf("expected output %q, got %q", expectedOut, out.String())
+	}
+	if posted := loop.Mq.Pull(); len(posted) != 2 {
+		t.Errorf("expected 2 batches posted, got %+v", posted)
+	}
+
+	err := c.send("figure 2 2")
+	serverErr, ok := err.(*ServerError)
+	if !ok || !strings.HasPrefix(serverErr.Status, "400") || len(serverErr.Lines) != 1 {
+		t.Errorf("expected a 400 ServerError with one diagnostic, got %v", err)
+	}
+}
+
+func TestREPL_ServerOnlyCommands(t *testing.T) {
+	var posted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /help", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(lang.Default.Help() + "sparkle x y                              A command of a newer server.\n"))
+	})
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = append(posted, string(body))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	c := &client{base: server.URL, http: server.Client()}
+
+	if _, _, candidates := complete("spa", 3, c.commandNames()); !reflect.DeepEqual(candidates, []string{"sparkle"}) {
+		t.Errorf("expected the server command to be completed, got %v", candidates)
+	}
+
+	input := "sparkle 0.5 0.5\nsparkl 0.5 0.5\n"
+	var out strings.Builder
+	if err := repl(c, scannerReader{bufio.NewScanner(strings.NewReader(input))}, &out); err != nil {
+		t.Fatalf("repl failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "error: column 1: unknown command \"sparkl\"") {
+		t.Errorf("expected a local error for the unknown command, got %q", out.String())
+	}
+	if !reflect.DeepEqual(posted, []string{"sparkle 0.5 0.5"}) {
+		t.Errorf("expected the server command to be sent, got %q", posted)
+	}
+}
+
+func TestREPL_CommandNamesFallbackIsCached(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := &client{base: server.URL, http: server.Client()}
+
+	for i := 0; i < 3; i++ {
+		if names := c.commandNames(); !reflect.DeepEqual(names, lang.Default.Names()) {
+			t.Errorf("expected the local commands, got %v", names)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected /help to be requested once, got %d requests", requests)
+	}
+}
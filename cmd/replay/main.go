@@ -0,0 +1,167 @@
+// cmd/replay/main.go
+
+// Command replay feeds a journal recorded by "painter -journal" into a
+// headless painter loop and writes the resulting frames and final state.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+var (
+	speed     = flag.Float64("speed", 1, "Replay speed, e.g. 10 for ten times faster")
+	width     = flag.Int("width", 800, "Canvas width in pixels (must match the recording)")
+	height    = flag.Int("height", 800, "Canvas height in pixels (must match the recording)")
+	framesDir = flag.String("frames", "", "Directory to write every frame to as frame-NNNN.png")
+	finalPNG  = flag.String("png", "", "File to write the final frame to")
+	antialias = flag.Bool("antialias", false, "Render frames with anti-aliased edges")
+	sprites   = flag.String("sprites", painter.SpritesDir, "Directory of the images placed with 'image'")
+	scenesDir = flag.String("scenes", painter.ScenesDir, "Directory of the scenes loaded with 'load' (never written to)")
+	exportDir = flag.String("exports", "", "Directory for files written by 'export-svg' and 'record' (discarded if empty)")
+)
+
+// frameWriter receives textures from the loop and saves them as PNG files.
+type frameWriter struct {
+	dir   string
+	count int
+	last  *offscreen.Texture
+}
+
+func (fw *frameWriter) Update(t screen.Texture) {
+	tex := t.(*offscreen.Texture)
+	fw.last = tex
+	fw.count++
+	if fw.dir == "" {
+		return
+	}
+	if err := writePNG(filepath.Join(fw.dir, fmt.Sprintf("frame-%04d.png", fw.count)), tex); err != nil {
+		log.Printf("Replay: Error writing frame %d: %v", fw.count, err)
+	}
+}
+
+func writePNG(path string, tex *offscreen.Texture) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, tex.Image()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sandbox points the painter at temporary scene and export directories, so
+// that the journaled save, export-svg and record operations do not overwrite
+// real files. Scenes from scenes are copied first to keep load working.
+// An empty exports selects a temporary directory. The returned function
+// removes the temporary directories.
+func sandbox(scenes, exports string) (func(), error) {
+	tmp, err := os.MkdirTemp("", "replay-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+	dir := filepath.Join(tmp, "scenes")
+	if err := copyScenes(scenes, dir); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if exports == "" {
+		exports = filepath.Join(tmp, "exports")
+	}
+	painter.ScenesDir = dir
+	painter.ExportDir = exports
+	return cleanup, nil
+}
+
+// copyScenes copies the scene files from src to dst. A missing src is treated
+// as an empty directory.
+func copyScenes(src, dst string) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(src, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, filepath.Base(file)), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] JOURNAL\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	painter.SpritesDir = *sprites
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := lang.ReadJournal(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to read journal: %v", err)
+	}
+	if *framesDir != "" {
+		if err := os.MkdirAll(*framesDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	cleanup, err := sandbox(*scenesDir, *exportDir)
+	if err != nil {
+		log.Fatalf("Failed to prepare scene and export directories: %v", err)
+	}
+	defer cleanup()
+
+	receiver := &frameWriter{dir: *framesDir}
+	loop := painter.NewLoop(receiver, *width, *height)
+	loop.Speed = *speed
+	loop.Antialias = *antialias
+	loop.Start(offscreen.Screen{})
+	log.Printf("Replaying %d journal entries at speed x%g", len(entries), *speed)
+	lang.Replay(loop, entries, *speed)
+	state := loop.GetState()
+	loop.Stop()
+
+	if *finalPNG != "" && receiver.last != nil {
+		if err := writePNG(*finalPNG, receiver.last); err != nil {
+			log.Fatalf("Failed to write final frame: %v", err)
+		}
+	}
+	log.Printf("Replay finished after %d frames", receiver.count)
+
+	// Фінальний стан виводимо у stdout, щоб його можна було порівняти з очікуваним
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(state.Snapshot()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestSandbox_ReplayLeavesScenesUntouched(t *testing.T) {
+	prevScenes, prevExports := painter.ScenesDir, painter.ExportDir
+	defer func() { painter.ScenesDir, painter.ExportDir = prevScenes, prevExports }()
+
+	scenes := t.TempDir()
+	painter.ScenesDir = scenes
+	if err := painter.SaveScene("demo", painter.State{BgColor: color.NRGBA{G: 0xff, A: 0xff}, WindowWidth: 200, WindowHeight: 200}); err != nil {
+		t.Fatalf("SaveScene failed: %v", err)
+	}
+	original, err := os.ReadFile(filepath.Join(scenes, "demo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	journal := `{"at":"0s","batches":[[{"op":"load","name":"demo"},{"op":"figure","x":0.5,"y":0.5},{"op":"update"}]]}
+{"at":"1ms","batches":[[{"op":"save","name":"demo"},{"op":"save","name":"other"},{"op":"export-svg","name":"demo"}]]}
+`
+	entries, err := lang.ReadJournal(strings.NewReader(journal))
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	cleanup, err := sandbox(scenes, "")
+	if err != nil {
+		t.Fatalf("sandbox failed: %v", err)
+	}
+	defer cleanup()
+
+	loop := painter.NewLoop(&frameWriter{}, 200, 200)
+	loop.Start(offscreen.Screen{})
+	lang.Replay(loop, entries, 1)
+	state := loop.GetState().Snapshot()
+	loop.Stop()
+
+	if state.Background != "#00ff00" || len(state.Figures) != 1 {
+		t.Errorf("expected the copied scene to be loaded, got %+v", state)
+	}
+	files, err := os.ReadDir(scenes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "demo.json" {
+		t.Errorf("expected only demo.json in the scenes directory, got %v", files)
+	}
+	if data, err := os.ReadFile(filepath.Join(scenes, "demo.json")); err != nil || string(data) != string(original) {
+		t.Errorf("expected demo.json to be unchanged, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(painter.ScenesDir, "other.json")); err != nil {
+		t.Errorf("expected the replayed save in the temporary directory: %v", err)
+	}
+}
@@ -3,17 +3,20 @@ module github.com/roman-mazur/architecture-lab-3
 go 1.24 // Or your Go version
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0
 	golang.org/x/mobile v0.0.0-20250408133729-978277e7eaf7
+	golang.org/x/term v0.31.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
-require golang.org/x/image v0.26.0 // indirect; Indirect dependency, ensure compatibility
+require golang.org/x/image v0.26.0 // Anti-aliased rendering, text and image scaling
 
 require (
 	dmitri.shuralyov.com/gpu/mtl v0.0.0-20221208032759-85de2813cf6b // indirect
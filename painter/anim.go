@@ -0,0 +1,101 @@
+package painter
+
+import (
+	"image"
+	"log"
+	"math"
+	"time"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// FrameInterval is the period between animation frames produced by the loop.
+const FrameInterval = time.Second / 60
+
+// Easing maps linear animation progress in [0, 1] to eased progress in [0, 1].
+type Easing func(t float64) float64
+
+// Easings lists the easing functions available by name to AnimateMove.
+var Easings = map[string]Easing{
+	"linear":      func(t float64) float64 { return t },
+	"ease-in":     func(t float64) float64 { return t * t },
+	"ease-out":    func(t float64) float64 { return t * (2 - t) },
+	"ease-in-out": func(t float64) float64 { return (1 - math.Cos(math.Pi*t)) / 2 },
+}
+
+// AnimateMove smoothly applies a relative offset to all figures over Duration.
+// The loop interpolates the offset frame by frame using the named Easing
+// ("linear" if empty) and sends a screen update on every frame.
+type AnimateMove struct {
+	X, Y     float64
+	Duration time.Duration
+	Easing   string
+}
+
+// Do застосовує зміщення одразу; покадрову анімацію виконує Loop.
+func (op AnimateMove) Do(s *State, t screen.Texture) bool {
+	log.Printf("AnimateMove.Do: Applying (%.2f, %.2f) at once outside of the loop", op.X, op.Y)
+	return Move{X: op.X, Y: op.Y}.Do(s, t)
+}
+
+// animation tracks the progress of a single AnimateMove in the loop.
+type animation struct {
+	start    time.Time
+	duration time.Duration
+	easing   Easing
+	total    image.Point // Повне піксельне зміщення
+	applied  image.Point // Частина зміщення, вже додана до MoveOffset
+}
+
+// animate registers a new animation. Must be called from the loop goroutine.
+func (l *Loop) animate(op AnimateMove) {
+	easing, ok := Easings[op.Easing]
+	if !ok {
+		easing = Easings["linear"]
+	}
+	a := &animation{
+		start:    time.Now(),
+		duration: l.scale(op.Duration),
+		easing:   easing,
+		total:    image.Pt(int(op.X*float64(l.state.WindowWidth)), int(op.Y*float64(l.state.WindowHeight))),
+	}
+	log.Printf("Loop: Starting animation of %+v over %v", a.total, a.duration)
+	if len(l.animations) == 0 {
+		l.frames.Reset(FrameInterval)
+	}
+	l.animations = append(l.animations, a)
+}
+
+// advance moves every running animation to its position at now and drops the
+// finished ones. Returns true if the state changed.
+func (l *Loop) advance(now time.Time) (changed bool) {
+	running := l.animations[:0]
+	for _, a := range l.animations {
+		progress := 1.0
+		if a.duration > 0 {
+			progress = math.Min(1, float64(now.Sub(a.start))/float64(a.duration))
+		}
+		e := a.easing(progress)
+		// Зміщення накопичується інкрементно, тому анімації комбінуються з 'move' та одна з одною
+		target := image.Pt(int(math.Round(float64(a.total.X)*e)), int(math.Round(float64(a.total.Y)*e)))
+		if delta := target.Sub(a.applied); delta != (image.Point{}) {
+			l.state.MoveOffset = l.state.MoveOffset.Add(delta)
+			a.applied = target
+			changed = true
+		}
+		if progress < 1 {
+			running = append(running, a)
+		}
+	}
+	l.animations = running
+	if len(l.animations) == 0 {
+		l.frames.Stop()
+	}
+	return changed
+}
+
+// cancelAnimations drops all running animations, e.g. after a reset.
+func (l *Loop) cancelAnimations() {
+	l.animations = nil
+	l.frames.Stop()
+}
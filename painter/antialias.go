@@ -0,0 +1,121 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+
+	"golang.org/x/exp/shiny/screen"
+	"golang.org/x/image/vector"
+)
+
+// kappa is the distance of the control points of a cubic Bézier curve
+// approximating a quarter of a circle, relative to the radius.
+const kappa = 0.5522847498
+
+// AntialiasRenderer draws on an RGBA image with anti-aliased edges of
+// polygons and ellipses. Axis-aligned rectangles have integer edges and
+// are filled exactly, like with ImageRenderer.
+type AntialiasRenderer struct {
+	Img *image.RGBA
+	z   vector.Rasterizer
+}
+
+func (ar *AntialiasRenderer) Bounds() image.Rectangle { return ar.Img.Bounds() }
+
+func (ar *AntialiasRenderer) Clear(c color.Color) {
+	draw.Draw(ar.Img, ar.Img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (ar *AntialiasRenderer) FillBackground(f *BgFillOp) {
+	ImageRenderer{Img: ar.Img}.FillBackground(f)
+}
+
+func (ar *AntialiasRenderer) FillText(origin image.Point, text string, size float64, c color.Color) {
+	// Гліфи шрифту і так растеризуються зі згладжуванням
+	ImageRenderer{Img: ar.Img}.FillText(origin, text, size, c)
+}
+
+func (ar *AntialiasRenderer) DrawImage(r image.Rectangle, img image.Image) {
+	// Масштабування вже білінійне, додаткове згладжування не потрібне
+	ImageRenderer{Img: ar.Img}.DrawImage(r, img)
+}
+
+func (ar *AntialiasRenderer) FillRect(r image.Rectangle, c color.Color) {
+	draw.Draw(ar.Img, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func (ar *AntialiasRenderer) FillPolygon(pts []image.Point, c color.Color) {
+	if len(pts) < 3 {
+		return
+	}
+	var box image.Rectangle
+	for _, p := range pts {
+		box = box.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	// Растеризатор охоплює лише обмежувальний прямокутник, а не все зображення
+	ar.fill(box, c, func(o image.Point) {
+		ar.z.MoveTo(float32(pts[0].X-o.X), float32(pts[0].Y-o.Y))
+		for _, p := range pts[1:] {
+			ar.z.LineTo(float32(p.X-o.X), float32(p.Y-o.Y))
+		}
+	})
+}
+
+func (ar *AntialiasRenderer) FillEllipse(center image.Point, rx, ry int, c color.Color) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	box := image.Rect(center.X-rx, center.Y-ry, center.X+rx, center.Y+ry)
+	ar.fill(box, c, func(o image.Point) {
+		cx, cy := float32(center.X-o.X), float32(center.Y-o.Y)
+		x, y := float32(rx), float32(ry)
+		kx, ky := x*kappa, y*kappa
+		// Чотири чверті еліпса кубічними кривими Безьє
+		ar.z.MoveTo(cx+x, cy)
+		ar.z.CubeTo(cx+x, cy+ky, cx+kx, cy+y, cx, cy+y)
+		ar.z.CubeTo(cx-kx, cy+y, cx-x, cy+ky, cx-x, cy)
+		ar.z.CubeTo(cx-x, cy-ky, cx-kx, cy-y, cx, cy-y)
+		ar.z.CubeTo(cx+kx, cy-y, cx+x, cy-ky, cx+x, cy)
+	})
+}
+
+// fill rasterises the path drawn by path, relative to the origin passed to
+// it, within the part of box inside the image and composites it over the image.
+func (ar *AntialiasRenderer) fill(box image.Rectangle, c color.Color, path func(origin image.Point)) {
+	clipped := box.Intersect(ar.Img.Bounds())
+	if clipped.Empty() {
+		return
+	}
+	// Растеризатор покриває лише видиму частину, інакше фігури за лівим
+	// або верхнім краєм зміщуються
+	ar.z.Reset(clipped.Dx(), clipped.Dy())
+	path(clipped.Min)
+	ar.z.ClosePath()
+	// Часткове покриття на краях змішується з тим, що вже намальовано
+	ar.z.DrawOp = draw.Over
+	ar.z.Draw(ar.Img, clipped, image.NewUniform(c), image.Point{})
+}
+
+// RenderImage draws the state on img, anti-aliased if the state asks for it.
+func RenderImage(img *image.RGBA, s State) {
+	if s.Antialias {
+		Render(&AntialiasRenderer{Img: img}, s)
+	} else {
+		Render(ImageRenderer{Img: img}, s)
+	}
+}
+
+// Antialias defines the operation for switching anti-aliased rendering on
+// or off. Anti-aliased frames are rendered into a buffer by the loop and
+// uploaded to the texture.
+type Antialias struct {
+	Enabled bool
+}
+
+func (op Antialias) Do(s *State, t screen.Texture) bool {
+	log.Printf("Antialias.Do: Anti-aliasing enabled: %v", op.Enabled)
+	s.Antialias = op.Enabled
+	return false // Режим застосовується під час наступного Update
+}
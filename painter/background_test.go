@@ -0,0 +1,91 @@
+package painter_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	bgBlack = color.NRGBA{A: 0xff}
+	bgWhite = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func renderFill(t *testing.T, op painter.BgFill) *image.RGBA {
+	t.Helper()
+	s := painter.State{WindowWidth: 100, WindowHeight: 100}
+	op.Do(&s, nil)
+	require.NotNil(t, s.BgFill)
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+	texture := offscreen.NewTexture(image.Pt(100, 100))
+	painter.Render(painter.TextureRenderer{T: texture}, s)
+	assert.True(t, bytes.Equal(texture.Image().Pix, img.Pix), "texture and image backgrounds differ")
+	return img
+}
+
+func TestBgFill_Gradients(t *testing.T) {
+	// Під кутом 90° градієнт іде згори донизу, тож рядки однакові
+	img := renderFill(t, painter.BgFill{Kind: painter.LinearGradient, From: bgBlack, To: bgWhite, Angle: 90})
+	assert.Less(t, img.RGBAAt(50, 0).R, uint8(0x04))
+	assert.Greater(t, img.RGBAAt(50, 99).R, uint8(0xfb))
+	assert.InDelta(t, 0x80, int(img.RGBAAt(10, 50).R), 2)
+	assert.Equal(t, img.RGBAAt(0, 30), img.RGBAAt(99, 30))
+
+	img = renderFill(t, painter.BgFill{Kind: painter.RadialGradient, From: bgWhite, To: bgBlack})
+	assert.Greater(t, img.RGBAAt(50, 50).R, uint8(0xfb))
+	assert.Less(t, img.RGBAAt(0, 0).R, uint8(0x04))
+	assert.Equal(t, img.RGBAAt(19, 50), img.RGBAAt(80, 50))
+}
+
+func TestBgFill_Patterns(t *testing.T) {
+	img := renderFill(t, painter.BgFill{Kind: painter.Checkerboard, From: bgBlack, To: bgWhite, Size: 0.1})
+	black, white := color.RGBA{A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	assert.Equal(t, black, img.RGBAAt(5, 5))
+	assert.Equal(t, white, img.RGBAAt(15, 5))
+	assert.Equal(t, white, img.RGBAAt(5, 15))
+	assert.Equal(t, black, img.RGBAAt(15, 15))
+
+	// Розмір за замовчуванням - 5 пікселів для вікна 100x100
+	img = renderFill(t, painter.BgFill{Kind: painter.Stripes, From: bgBlack, To: bgWhite})
+	assert.Equal(t, black, img.RGBAAt(2, 50))
+	assert.Equal(t, white, img.RGBAAt(7, 50))
+	assert.Equal(t, black, img.RGBAAt(12, 0))
+
+	// Смуги під кутом 90° горизонтальні
+	img = renderFill(t, painter.BgFill{Kind: painter.Stripes, From: bgBlack, To: bgWhite, Angle: 90})
+	assert.Equal(t, black, img.RGBAAt(50, 2))
+	assert.Equal(t, white, img.RGBAAt(50, 7))
+}
+
+func TestBgFill_SolidBackgroundRemovesFill(t *testing.T) {
+	s := painter.State{WindowWidth: 100, WindowHeight: 100}
+	painter.BgFill{Kind: painter.Checkerboard, From: bgBlack, To: bgWhite}.Do(&s, nil)
+	assert.Equal(t, painter.DefaultPatternSize, s.BgFill.Size)
+	painter.GreenBg{}.Do(&s, nil)
+	assert.Nil(t, s.BgFill)
+}
+
+func TestWriteSVG_BgFill(t *testing.T) {
+	s := painter.State{WindowWidth: 100, WindowHeight: 50}
+	painter.BgFill{Kind: painter.LinearGradient, From: bgBlack, To: color.NRGBA{R: 0xff, A: 0x80}}.Do(&s, nil)
+	var b strings.Builder
+	require.NoError(t, painter.WriteSVG(&b, s))
+	svg := b.String()
+	assert.Contains(t, svg, `<linearGradient id="bg" gradientUnits="userSpaceOnUse" x1="0" y1="25" x2="100" y2="25">`)
+	assert.Contains(t, svg, `<stop offset="1" stop-color="#ff0000" stop-opacity="0.502"/>`)
+	assert.Contains(t, svg, `<rect x="0" y="0" width="100" height="50" fill="url(#bg)"/>`)
+
+	painter.BgFill{Kind: painter.Checkerboard, From: bgBlack, To: bgWhite, Size: 0.2}.Do(&s, nil)
+	b.Reset()
+	require.NoError(t, painter.WriteSVG(&b, s))
+	assert.Contains(t, b.String(), `<pattern id="bg" patternUnits="userSpaceOnUse" width="20" height="20">`)
+}
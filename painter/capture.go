@@ -0,0 +1,426 @@
+package painter
+
+import (
+	"bufio"
+	"compress/lzw"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Capture formats supported by RecordStart.
+const (
+	CaptureGIF = "gif" // Animated GIF written to ExportDir/<name>.gif
+	CapturePNG = "png" // Numbered frames written to ExportDir/<name>/frame-NNNN.png
+)
+
+// Limits of a capture. A capture that reaches either of them is stopped and
+// written, and RecordStart with a longer Duration is rejected.
+const (
+	MaxCaptureDuration = time.Minute
+	MaxCaptureFrames   = 1000
+)
+
+// RecordStart starts capturing every frame the loop sends to the Receiver.
+// The frames are written when RecordStop is posted or, if Duration is set,
+// once the duration has passed. Starting a new capture finishes the previous one.
+type RecordStart struct {
+	Name     string
+	Format   string // CaptureGIF if empty
+	Duration time.Duration
+}
+
+// Do нічого не робить: захопленням кадрів керує Loop.
+func (op RecordStart) Do(s *State, t screen.Texture) bool {
+	log.Printf("RecordStart.Do: Capture %q can only be recorded by the loop", op.Name)
+	return false
+}
+
+// RecordStop finishes the current capture once the batch it belongs to is
+// processed, so the frame of that batch is included, and writes the frames.
+type RecordStop struct {
+	Result chan<- error // Optional buffered channel receiving the outcome of the write
+
+	capture int // Ідентифікатор запису для зупинки за тривалістю, 0 для поточного запису
+}
+
+func (op RecordStop) Do(s *State, t screen.Texture) bool {
+	log.Println("RecordStop.Do: No capture outside of the loop")
+	if op.Result != nil {
+		op.Result <- errNoCapture
+	}
+	return false
+}
+
+var errNoCapture = errors.New("no capture is running")
+
+// capture collects the frames of a recording as snapshots, which are much
+// smaller than images, and renders them when written.
+type capture struct {
+	id           int // Номер запису, щоб зупинка за тривалістю не завершила наступний
+	name, format string
+	speed        float64
+	start        time.Time
+	frames       []Snapshot
+	times        []time.Time
+
+	stopping bool         // RecordStop received, the capture ends after the current batch
+	result   chan<- error // Result channel of the RecordStop
+}
+
+// ValidCaptureDuration checks the duration of a RecordStart operation.
+func ValidCaptureDuration(d time.Duration) error {
+	if d < 0 || d > MaxCaptureDuration {
+		return fmt.Errorf("capture duration %v out of range, expected at most %v", d, MaxCaptureDuration)
+	}
+	return nil
+}
+
+// ValidCaptureFormat checks the format of a RecordStart operation.
+func ValidCaptureFormat(format string) error {
+	if format != "" && format != CaptureGIF && format != CapturePNG {
+		return fmt.Errorf("unknown capture format %q, expected %s or %s", format, CaptureGIF, CapturePNG)
+	}
+	return nil
+}
+
+// startCapture begins a new capture. Must be called from the loop goroutine.
+func (l *Loop) startCapture(op RecordStart) {
+	if err := ValidSceneName(op.Name); err != nil {
+		log.Printf("Loop: Not recording: %v", err)
+		return
+	}
+	if err := ValidCaptureFormat(op.Format); err != nil {
+		log.Printf("Loop: Not recording: %v", err)
+		return
+	}
+	if err := ValidCaptureDuration(op.Duration); err != nil {
+		log.Printf("Loop: Not recording: %v", err)
+		return
+	}
+	if l.capture != nil {
+		l.stopCapture()
+	}
+	format := op.Format
+	if format == "" {
+		format = CaptureGIF
+	}
+	l.captures++
+	l.capture = &capture{id: l.captures, name: op.Name, format: format, speed: l.Speed, start: time.Now()}
+	log.Printf("Loop: Recording frames to %q (%s)", op.Name, format)
+	if op.Duration > 0 {
+		l.schedule(op.Duration, OperationList{RecordStop{capture: l.capture.id}})
+	}
+}
+
+// captureFrame adds a frame to the running capture, if any. The capture is
+// stopped once it reaches MaxCaptureFrames or MaxCaptureDuration.
+func (l *Loop) captureFrame(snap Snapshot, at time.Time) {
+	c := l.capture
+	if c == nil || len(c.frames) >= MaxCaptureFrames {
+		return
+	}
+	if at.Sub(c.start) > MaxCaptureDuration {
+		log.Printf("Loop: Capture %q reached %v, stopping", c.name, MaxCaptureDuration)
+		c.stopping = true
+		return
+	}
+	c.frames = append(c.frames, snap)
+	c.times = append(c.times, at)
+	if len(c.frames) >= MaxCaptureFrames {
+		log.Printf("Loop: Capture %q reached %d frames, stopping", c.name, MaxCaptureFrames)
+		c.stopping = true
+	}
+}
+
+// requestStopCapture marks the running capture to be stopped once the
+// current batch is processed, so that its frame is still recorded.
+// A stop scheduled by RecordStart.Duration is ignored if its capture has
+// already ended.
+func (l *Loop) requestStopCapture(op RecordStop) {
+	if op.capture != 0 && (l.capture == nil || l.capture.id != op.capture) {
+		log.Printf("Loop: Capture %d already stopped", op.capture)
+		return
+	}
+	result := op.Result
+	if l.capture == nil {
+		log.Println("Loop: record stop without a running capture")
+		if result != nil {
+			result <- errNoCapture
+		}
+		return
+	}
+	l.capture.stopping = true
+	l.capture.result = result
+}
+
+// stopCapture ends the running capture and writes it in the background,
+// so that encoding does not delay the loop.
+func (l *Loop) stopCapture() {
+	c := l.capture
+	l.capture = nil
+	if c == nil {
+		return
+	}
+	log.Printf("Loop: Recording %q stopped after %d frames", c.name, len(c.frames))
+	go func() {
+		err := c.write()
+		if err != nil {
+			log.Printf("Loop: Failed to write capture %q: %v", c.name, err)
+		} else {
+			log.Printf("Loop: Capture %q written", c.name)
+		}
+		if c.result != nil {
+			c.result <- err
+		}
+	}()
+}
+
+// flushCapture writes the running capture, if any, before the loop stops.
+func (l *Loop) flushCapture() {
+	if c := l.capture; c != nil {
+		l.capture = nil
+		err := c.write()
+		if err != nil {
+			log.Printf("Loop: Failed to write capture %q: %v", c.name, err)
+		}
+		if c.result != nil {
+			c.result <- err
+		}
+	}
+}
+
+// write renders the captured frames and saves them in ExportDir. Frames are
+// rendered and encoded one at a time, so that only one image is in memory.
+func (c *capture) write() error {
+	if len(c.frames) == 0 {
+		return errors.New("no frames were captured")
+	}
+	if err := os.MkdirAll(ExportDir, 0o755); err != nil {
+		return err
+	}
+	if c.format == CapturePNG {
+		return c.writePNGs()
+	}
+	return c.writeGIF()
+}
+
+// render draws frame i of the capture.
+func (c *capture) render(i int) (*image.RGBA, error) {
+	snap := c.frames[i]
+	s, err := snap.toState(snap.Width, snap.Height)
+	if err != nil {
+		return nil, fmt.Errorf("frame %d: %w", i+1, err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, snap.Width, snap.Height))
+	RenderImage(img, s)
+	return img, nil
+}
+
+func (c *capture) writePNGs() error {
+	dir := filepath.Join(ExportDir, c.name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i := range c.frames {
+		img, err := c.render(i)
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i+1)))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *capture) writeGIF() error {
+	pal, err := c.palette()
+	if err != nil {
+		return err
+	}
+	var width, height int
+	for _, snap := range c.frames {
+		width, height = max(width, snap.Width), max(height, snap.Height)
+	}
+	f, err := os.Create(filepath.Join(ExportDir, c.name+".gif"))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	gw := newGIFWriter(w, width, height, pal)
+	for i := range c.frames {
+		img, err := c.render(i)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		frame := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(frame, frame.Rect, img, img.Rect.Min, draw.Src)
+		gw.writeFrame(frame, c.delay(i))
+	}
+	if err := gw.close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// delay returns how long frame i is shown, in 100ths of a second of scene
+// time, i.e. real time multiplied by the loop speed.
+func (c *capture) delay(i int) int {
+	if i+1 >= len(c.times) {
+		return 100 // Останній кадр показуємо секунду перед повтором
+	}
+	d := c.times[i+1].Sub(c.times[i])
+	if c.speed > 0 {
+		d = time.Duration(float64(d) * c.speed)
+	}
+	return max(int(d.Round(10*time.Millisecond)/(10*time.Millisecond)), 1)
+}
+
+// palette returns the exact colors of the frames if there are at most 256
+// of them, and a generic palette otherwise. The frames are rendered one by
+// one and rendered again when encoded, trading time for memory.
+func (c *capture) palette() (color.Palette, error) {
+	seen := map[color.RGBA]struct{}{}
+	var pal color.Palette
+	for i := range c.frames {
+		img, err := c.render(i)
+		if err != nil {
+			return nil, err
+		}
+		for p := 0; p+3 < len(img.Pix); p += 4 {
+			c := color.RGBA{R: img.Pix[p], G: img.Pix[p+1], B: img.Pix[p+2], A: img.Pix[p+3]}
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			if len(seen) == 256 {
+				return palette.Plan9, nil
+			}
+			seen[c] = struct{}{}
+			pal = append(pal, c)
+		}
+	}
+	return pal, nil
+}
+
+// gifWriter encodes an animated GIF frame by frame. Unlike gif.EncodeAll it
+// does not need all frames at once. Every frame uses the global palette.
+type gifWriter struct {
+	w     io.Writer
+	bits  int // Розмір палітри як степінь двійки
+	err   error
+	block [256]byte
+}
+
+// newGIFWriter writes the GIF header with the global palette and the
+// extension that makes the animation loop forever.
+func newGIFWriter(w io.Writer, width, height int, pal color.Palette) *gifWriter {
+	gw := &gifWriter{w: w, bits: 1}
+	for 1<<gw.bits < len(pal) {
+		gw.bits++
+	}
+	header := []byte("GIF89a")
+	header = binary.LittleEndian.AppendUint16(header, uint16(width))
+	header = binary.LittleEndian.AppendUint16(header, uint16(height))
+	header = append(header, 0x80|byte(gw.bits-1)<<4|byte(gw.bits-1), 0, 0)
+	for i := 0; i < 1<<gw.bits; i++ {
+		if i < len(pal) {
+			r, g, b, _ := pal[i].RGBA()
+			header = append(header, byte(r>>8), byte(g>>8), byte(b>>8))
+		} else {
+			header = append(header, 0, 0, 0)
+		}
+	}
+	header = append(header, 0x21, 0xff, 11)
+	header = append(header, "NETSCAPE2.0"...)
+	header = append(header, 3, 1, 0, 0, 0)
+	gw.write(header)
+	return gw
+}
+
+func (gw *gifWriter) write(p []byte) {
+	if gw.err == nil {
+		_, gw.err = gw.w.Write(p)
+	}
+}
+
+// writeFrame writes a frame shown for delay 100ths of a second.
+func (gw *gifWriter) writeFrame(frame *image.Paletted, delay int) {
+	b := frame.Rect
+	head := []byte{0x21, 0xf9, 4, 0}
+	head = binary.LittleEndian.AppendUint16(head, uint16(delay))
+	head = append(head, 0, 0, 0x2c)
+	for _, v := range []int{b.Min.X, b.Min.Y, b.Dx(), b.Dy()} {
+		head = binary.LittleEndian.AppendUint16(head, uint16(v))
+	}
+	litWidth := max(gw.bits, 2) // GIF не дозволяє мінімальний розмір коду менше 2
+	head = append(head, 0, byte(litWidth))
+	gw.write(head)
+
+	// Стиснені дані записуються блоками до 255 байтів
+	lw := lzw.NewWriter(gw, lzw.LSB, litWidth)
+	for y := b.Min.Y; y < b.Max.Y && gw.err == nil; y++ {
+		start := frame.PixOffset(b.Min.X, y)
+		if _, err := lw.Write(frame.Pix[start : start+b.Dx()]); err != nil && gw.err == nil {
+			gw.err = err
+		}
+	}
+	if err := lw.Close(); err != nil && gw.err == nil {
+		gw.err = err
+	}
+	gw.flushBlock()
+	gw.write([]byte{0})
+}
+
+// Write implements io.Writer for the LZW encoder, splitting data into sub-blocks.
+func (gw *gifWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := copy(gw.block[1+gw.block[0]:], p)
+		gw.block[0] += byte(k)
+		p = p[k:]
+		if gw.block[0] == 255 {
+			gw.flushBlock()
+		}
+	}
+	return n, gw.err
+}
+
+func (gw *gifWriter) flushBlock() {
+	if gw.block[0] > 0 {
+		gw.write(gw.block[:1+gw.block[0]])
+		gw.block[0] = 0
+	}
+}
+
+// close writes the GIF trailer and returns the first error that occurred.
+func (gw *gifWriter) close() error {
+	gw.write([]byte{0x3b})
+	return gw.err
+}
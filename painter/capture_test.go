@@ -0,0 +1,162 @@
+package painter_test
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useExportDir(t *testing.T) string {
+	dir := t.TempDir()
+	prev := painter.ExportDir
+	painter.ExportDir = dir
+	t.Cleanup(func() { painter.ExportDir = prev })
+	return dir
+}
+
+func TestLoop_RecordGIF(t *testing.T) {
+	dir := useExportDir(t)
+	loop := painter.NewLoop(newMockReceiver(), 100, 100)
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	<-loop.Idle()
+
+	result := make(chan error, 1)
+	loop.Post(painter.OperationList{
+		painter.RecordStart{Name: "demo"},
+		painter.WhiteBg{},
+		painter.UpdateOp{},
+		painter.Wait{Duration: 30 * time.Millisecond},
+		painter.GreenBg{},
+		painter.UpdateOp{},
+		painter.RecordStop{Result: result},
+	})
+	select {
+	case err := <-result:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("capture was not written")
+	}
+
+	f, err := os.Open(filepath.Join(dir, "demo.gif"))
+	require.NoError(t, err)
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	require.NoError(t, err)
+	require.Len(t, anim.Image, 2)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), anim.Image[0].Rect)
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.RGBAModel.Convert(anim.Image[0].At(0, 0)))
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, color.RGBAModel.Convert(anim.Image[1].At(0, 0)))
+	assert.GreaterOrEqual(t, anim.Delay[0], 3)
+}
+
+func TestLoop_RecordPNGForDuration(t *testing.T) {
+	dir := useExportDir(t)
+	loop := painter.NewLoop(newMockReceiver(), 100, 100)
+	loop.Start(offscreen.Screen{})
+	<-loop.Idle()
+
+	loop.Post(painter.OperationList{
+		painter.RecordStart{Name: "frames", Format: painter.CapturePNG, Duration: 20 * time.Millisecond},
+		painter.UpdateOp{},
+		painter.UpdateOp{}, // Оновлення в одному пакеті дають один кадр
+		painter.Wait{Duration: 10 * time.Millisecond},
+		painter.UpdateOp{},
+	})
+	<-loop.Idle()
+	loop.Stop() // Запис уже зупинено за тривалістю, Stop чекає лише завершення циклу
+
+	assert.Eventually(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(dir, "frames", "frame-*.png"))
+		return len(files) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLoop_RecordDurationDoesNotStopNextCapture(t *testing.T) {
+	useExportDir(t)
+	loop := painter.NewLoop(newMockReceiver(), 100, 100)
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	<-loop.Idle()
+
+	first := make(chan error, 1)
+	loop.Post(painter.OperationList{
+		painter.RecordStart{Name: "first", Duration: 40 * time.Millisecond},
+		painter.UpdateOp{},
+		painter.RecordStop{Result: first},
+	})
+	require.NoError(t, <-first)
+	loop.Post(painter.OperationList{
+		painter.RecordStart{Name: "second"},
+		painter.UpdateOp{},
+	})
+	// Зупинка першого запису за тривалістю не повинна завершити другий
+	time.Sleep(80 * time.Millisecond)
+	<-loop.Idle()
+
+	second := make(chan error, 1)
+	loop.Post(painter.RecordStop{Result: second})
+	select {
+	case err := <-second:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("capture was not written")
+	}
+}
+
+func TestLoop_RecordRejectsLongDuration(t *testing.T) {
+	useExportDir(t)
+	loop := painter.NewLoop(newMockReceiver(), 100, 100)
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	<-loop.Idle()
+
+	result := make(chan error, 1)
+	loop.Post(painter.OperationList{
+		painter.RecordStart{Name: "long", Duration: painter.MaxCaptureDuration + time.Second},
+		painter.UpdateOp{},
+		painter.RecordStop{Result: result},
+	})
+	assert.Error(t, <-result)
+}
+
+func TestLoop_RecordStopsAtFrameLimit(t *testing.T) {
+	dir := useExportDir(t)
+	loop := painter.NewLoop(newMockReceiver(), 10, 10)
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	<-loop.Idle()
+
+	loop.Post(painter.RecordStart{Name: "limit"})
+	for i := 0; i < painter.MaxCaptureFrames+5; i++ {
+		// Кожен кадр в окремому пакеті, щоб оновлення не об'єднувались
+		if i%2 == 0 {
+			loop.Post(painter.OperationList{painter.WhiteBg{}, painter.UpdateOp{}})
+		} else {
+			loop.Post(painter.OperationList{painter.GreenBg{}, painter.UpdateOp{}})
+		}
+		<-loop.Idle()
+	}
+
+	var anim *gif.GIF
+	require.Eventually(t, func() bool {
+		f, err := os.Open(filepath.Join(dir, "limit.gif"))
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+		anim, err = gif.DecodeAll(f)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, anim.Image, painter.MaxCaptureFrames)
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, color.RGBAModel.Convert(anim.Image[1].At(0, 0)))
+}
@@ -0,0 +1,294 @@
+package painter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"slices"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// GroupOp represents a named group of elements that are moved, recolored,
+// hidden and deleted as one unit. Elements refer to their group by name.
+type GroupOp struct {
+	Name   string
+	Offset image.Point // Зміщення елементів групи на додачу до State.MoveOffset
+	Hidden bool        // Елементи прихованої групи не малюються
+}
+
+// ValidGroupName checks that the name is a valid group name.
+func ValidGroupName(name string) error {
+	if !sceneNameRe.MatchString(name) {
+		return fmt.Errorf("invalid group name %q: use up to 64 letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// GroupByName returns the group with the given name, or nil.
+func (s *State) GroupByName(name string) *GroupOp {
+	if i := s.groupIndex(name); i >= 0 {
+		return s.Groups[i]
+	}
+	return nil
+}
+
+func (s *State) groupIndex(name string) int {
+	return slices.IndexFunc(s.Groups, func(g *GroupOp) bool { return g.Name == name })
+}
+
+// updateGroup replaces the group with the given name by a modified copy,
+// like updateFigure. It reports whether the group exists.
+func (s *State) updateGroup(name string, update func(g *GroupOp)) bool {
+	i := s.groupIndex(name)
+	if i < 0 {
+		return false
+	}
+	g := *s.Groups[i]
+	update(&g)
+	s.Groups = slices.Clone(s.Groups)
+	s.Groups[i] = &g
+	return true
+}
+
+// offset returns the total offset of the elements of the group: the shared
+// move offset and the offset of the group itself ("" for no group).
+func (s *State) offset(group string) image.Point {
+	if g := s.GroupByName(group); g != nil {
+		return s.MoveOffset.Add(g.Offset)
+	}
+	return s.MoveOffset
+}
+
+// visible reports whether the elements of the group ("" for no group) are drawn.
+func (s *State) visible(group string) bool {
+	g := s.GroupByName(group)
+	return g == nil || !g.Hidden
+}
+
+// elementUpdate holds the functions that modify each kind of element.
+type elementUpdate struct {
+	figure func(f *FigureOp)
+	shape  func(sh *ShapeOp)
+	sprite func(sp *SpriteOp)
+	text   func(tx *TextOp)
+}
+
+// updateElement replaces the figure, shape, sprite or text label with the
+// given identifier by a modified copy. It reports whether the element exists.
+func (s *State) updateElement(id int, u elementUpdate) bool {
+	return s.updateFigure(id, u.figure) || s.updateShape(id, u.shape) || s.updateSprite(id, u.sprite) || s.updateText(id, u.text)
+}
+
+// hasElement reports whether there is a figure, shape, sprite or text label
+// with the given identifier.
+func (s *State) hasElement(id int) bool {
+	return slices.ContainsFunc(s.Figures, func(f *FigureOp) bool { return f.ID == id }) ||
+		slices.ContainsFunc(s.Shapes, func(sh *ShapeOp) bool { return sh.ID == id }) ||
+		slices.ContainsFunc(s.Sprites, func(sp *SpriteOp) bool { return sp.ID == id }) ||
+		slices.ContainsFunc(s.Texts, func(tx *TextOp) bool { return tx.ID == id })
+}
+
+// groupIDs returns the identifiers of the elements of the group.
+func (s *State) groupIDs(group string) []int {
+	var ids []int
+	for _, f := range s.Figures {
+		if f.Group == group {
+			ids = append(ids, f.ID)
+		}
+	}
+	for _, sh := range s.Shapes {
+		if sh.Group == group {
+			ids = append(ids, sh.ID)
+		}
+	}
+	for _, sp := range s.Sprites {
+		if sp.Group == group {
+			ids = append(ids, sp.ID)
+		}
+	}
+	for _, tx := range s.Texts {
+		if tx.Group == group {
+			ids = append(ids, tx.ID)
+		}
+	}
+	return ids
+}
+
+// regroup returns the update that moves elements to the group, shifting
+// their coordinates so that they stay in place on the screen.
+func (s *State) regroup(group string) elementUpdate {
+	target := s.offset(group)
+	delta := func(from string) image.Point { return s.offset(from).Sub(target) }
+	return elementUpdate{
+		figure: func(f *FigureOp) {
+			d := delta(f.Group)
+			f.X, f.Y, f.Group = f.X+d.X, f.Y+d.Y, group
+		},
+		shape: func(sh *ShapeOp) {
+			d := delta(sh.Group)
+			// Новий слайс точок, бо старий спільний з копіями стану
+			pts := make([]image.Point, len(sh.Points))
+			for i, p := range sh.Points {
+				pts[i] = p.Add(d)
+			}
+			sh.Points, sh.Group = pts, group
+		},
+		sprite: func(sp *SpriteOp) {
+			d := delta(sp.Group)
+			sp.X, sp.Y, sp.Group = sp.X+d.X, sp.Y+d.Y, group
+		},
+		text: func(tx *TextOp) {
+			d := delta(tx.Group)
+			tx.X, tx.Y, tx.Group = tx.X+d.X, tx.Y+d.Y, group
+		},
+	}
+}
+
+// removeElements removes the figures, shapes, sprites and text labels whose
+// layer and group match. New slices are created, so copies of the state
+// made by Loop.GetState are not affected.
+func (s *State) removeElements(match func(layer, group string) bool) {
+	s.Figures = slices.DeleteFunc(slices.Clone(s.Figures), func(f *FigureOp) bool { return match(f.Layer, f.Group) })
+	s.Shapes = slices.DeleteFunc(slices.Clone(s.Shapes), func(sh *ShapeOp) bool { return match(sh.Layer, sh.Group) })
+	s.Sprites = slices.DeleteFunc(slices.Clone(s.Sprites), func(sp *SpriteOp) bool { return match(sp.Layer, sp.Group) })
+	s.Texts = slices.DeleteFunc(slices.Clone(s.Texts), func(tx *TextOp) bool { return match(tx.Layer, tx.Group) })
+}
+
+// Group defines the operation for adding elements to a named group, which is
+// created if it does not exist. Elements keep their place on the screen when
+// they leave another group.
+type Group struct {
+	Name string
+	IDs  []int
+}
+
+func (op Group) Do(s *State, t screen.Texture) bool {
+	if err := ValidGroupName(op.Name); err != nil {
+		log.Printf("Group.Do: %v", err)
+		return false
+	}
+	for _, id := range op.IDs {
+		if !s.hasElement(id) {
+			log.Printf("Group.Do: No element with id %d, group %q not changed", id, op.Name)
+			return false
+		}
+	}
+	if s.groupIndex(op.Name) < 0 {
+		s.Groups = append(slices.Clone(s.Groups), &GroupOp{Name: op.Name})
+	}
+	update := s.regroup(op.Name)
+	for _, id := range op.IDs {
+		s.updateElement(id, update)
+	}
+	log.Printf("Group.Do: Added elements %v to group %q", op.IDs, op.Name)
+	return false // Не вимагає негайного Update
+}
+
+// Ungroup defines the operation for removing a group. Its elements stay in
+// place on the screen.
+type Ungroup struct {
+	Name string
+}
+
+func (op Ungroup) Do(s *State, t screen.Texture) bool {
+	i := s.groupIndex(op.Name)
+	if i < 0 {
+		log.Printf("Ungroup.Do: No group %q", op.Name)
+		return false
+	}
+	update := s.regroup("")
+	for _, id := range s.groupIDs(op.Name) {
+		s.updateElement(id, update)
+	}
+	s.Groups = slices.Delete(slices.Clone(s.Groups), i, i+1)
+	log.Printf("Ungroup.Do: Group %q removed", op.Name)
+	return false // Не вимагає негайного Update
+}
+
+// MoveGroup defines the operation for shifting the elements of a group by a
+// relative offset, like Move does for all elements.
+type MoveGroup struct {
+	Name string
+	X, Y float64
+}
+
+func (op MoveGroup) Do(s *State, t screen.Texture) bool {
+	d := relPoint(s, op.X, op.Y)
+	if !s.updateGroup(op.Name, func(g *GroupOp) { g.Offset = g.Offset.Add(d) }) {
+		log.Printf("MoveGroup.Do: No group %q", op.Name)
+		return false
+	}
+	log.Printf("MoveGroup.Do: Group %q moved by (%.2f, %.2f) -> pixel (%d, %d)", op.Name, op.X, op.Y, d.X, d.Y)
+	return false // Не вимагає негайного Update
+}
+
+// RecolorGroup defines the operation for setting the color of the figures,
+// shapes and text labels of a group. Sprites keep their own colors.
+// Elements keep their opacity unless the color is translucent.
+type RecolorGroup struct {
+	Name  string
+	Color color.Color
+}
+
+func (op RecolorGroup) Do(s *State, t screen.Texture) bool {
+	if s.groupIndex(op.Name) < 0 || op.Color == nil {
+		log.Printf("RecolorGroup.Do: No group %q or no color", op.Name)
+		return false
+	}
+	update := elementUpdate{
+		figure: func(f *FigureOp) { f.Color = op.recolor(f.Color) },
+		shape:  func(sh *ShapeOp) { sh.Color = op.recolor(sh.Color) },
+		sprite: func(sp *SpriteOp) {},
+		text:   func(tx *TextOp) { tx.Color = op.recolor(tx.Color) },
+	}
+	for _, id := range s.groupIDs(op.Name) {
+		s.updateElement(id, update)
+	}
+	log.Printf("RecolorGroup.Do: Group %q recolored to %v", op.Name, op.Color)
+	return false // Не вимагає негайного Update
+}
+
+// recolor returns the new color of an element. An opaque color keeps the
+// opacity the element already has, e.g. set by Opacity; a translucent one
+// replaces it.
+func (op RecolorGroup) recolor(current color.Color) color.Color {
+	if _, _, _, a := op.Color.RGBA(); a != 0xffff || current == nil {
+		return op.Color
+	}
+	_, _, _, a := current.RGBA()
+	return withOpacity(op.Color, float64(a)/0xffff)
+}
+
+// ShowGroup defines the operation for showing or hiding the elements of a group.
+type ShowGroup struct {
+	Name    string
+	Visible bool
+}
+
+func (op ShowGroup) Do(s *State, t screen.Texture) bool {
+	if !s.updateGroup(op.Name, func(g *GroupOp) { g.Hidden = !op.Visible }) {
+		log.Printf("ShowGroup.Do: No group %q", op.Name)
+		return false
+	}
+	log.Printf("ShowGroup.Do: Group %q visible: %t", op.Name, op.Visible)
+	return false // Не вимагає негайного Update
+}
+
+// DeleteGroup defines the operation for removing a group with all its elements.
+type DeleteGroup struct {
+	Name string
+}
+
+func (op DeleteGroup) Do(s *State, t screen.Texture) bool {
+	i := s.groupIndex(op.Name)
+	if i < 0 {
+		log.Printf("DeleteGroup.Do: No group %q", op.Name)
+		return false
+	}
+	s.removeElements(func(layer, group string) bool { return group == op.Name })
+	s.Groups = slices.Delete(slices.Clone(s.Groups), i, i+1)
+	log.Printf("DeleteGroup.Do: Group %q deleted", op.Name)
+	return false // Не вимагає негайного Update
+}
@@ -0,0 +1,84 @@
+package painter_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroups(t *testing.T) {
+	red, blue := color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0xff}
+	s := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	painter.Figure{X: 0.2, Y: 0.2, Size: 0.1}.Do(&s, nil)
+	painter.Circle{X: 0.3, Y: 0.3, R: 0.05, Color: red}.Do(&s, nil)
+	painter.Circle{X: 0.8, Y: 0.8, R: 0.05, Color: red}.Do(&s, nil)
+	painter.Group{Name: "logo", IDs: []int{1, 2}}.Do(&s, nil)
+	require.NotNil(t, s.GroupByName("logo"))
+	assert.Equal(t, "logo", s.Figures[0].Group)
+	assert.Equal(t, "", s.Shapes[1].Group)
+
+	render := func() *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		painter.Render(painter.ImageRenderer{Img: img}, s)
+		return img
+	}
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+	// Зміщення групи додається до спільного зміщення 'move'
+	painter.Move{X: 0.1, Y: 0}.Do(&s, nil)
+	painter.MoveGroup{Name: "logo", X: 0, Y: 0.5}.Do(&s, nil)
+	img := render()
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.RGBAAt(40, 80))
+	assert.Equal(t, white, img.RGBAAt(40, 30))
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.RGBAAt(90, 80))
+	assert.Equal(t, 1, s.FigureAt(image.Pt(30, 70)).ID)
+
+	painter.RecolorGroup{Name: "logo", Color: blue}.Do(&s, nil)
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, render().RGBAAt(40, 80))
+	assert.Equal(t, red, s.Shapes[1].Color)
+
+	// Непрозорий колір зберігає прозорість, задану 'opacity', напівпрозорий - замінює її
+	painter.Opacity{ID: 2, Value: 0.5}.Do(&s, nil)
+	painter.RecolorGroup{Name: "logo", Color: red}.Do(&s, nil)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0x80}, s.Shapes[0].Color)
+	painter.RecolorGroup{Name: "logo", Color: color.NRGBA{G: 0xff, A: 0x40}}.Do(&s, nil)
+	assert.Equal(t, color.NRGBA{G: 0xff, A: 0x40}, s.Shapes[0].Color)
+	painter.RecolorGroup{Name: "logo", Color: blue}.Do(&s, nil)
+	painter.Opacity{ID: 2, Value: 1}.Do(&s, nil)
+
+	painter.ShowGroup{Name: "logo"}.Do(&s, nil)
+	assert.Equal(t, white, render().RGBAAt(40, 80))
+	assert.Nil(t, s.FigureAt(image.Pt(30, 70)))
+	painter.ShowGroup{Name: "logo", Visible: true}.Do(&s, nil)
+
+	// Після розгрупування елементи залишаються на місці
+	before := render()
+	painter.Ungroup{Name: "logo"}.Do(&s, nil)
+	assert.Nil(t, s.GroupByName("logo"))
+	assert.Equal(t, image.Pt(20, 70), image.Pt(s.Figures[0].X, s.Figures[0].Y))
+	assert.Equal(t, before.Pix, render().Pix)
+
+	// Елемент, що переходить до іншої групи, також не зсувається
+	painter.Group{Name: "a", IDs: []int{2}}.Do(&s, nil)
+	painter.MoveGroup{Name: "a", X: 0.1, Y: 0.1}.Do(&s, nil)
+	moved := render()
+	painter.Group{Name: "b", IDs: []int{2, 3}}.Do(&s, nil)
+	assert.Equal(t, moved.Pix, render().Pix)
+	painter.Group{Name: "c", IDs: []int{3, 42}}.Do(&s, nil)
+	assert.Nil(t, s.GroupByName("c"))
+
+	restored := painter.State{WindowWidth: 100, WindowHeight: 100}
+	painter.Restore{Scene: s.Snapshot()}.Do(&restored, nil)
+	assert.Equal(t, s.Groups, restored.Groups)
+	assert.Equal(t, "b", restored.Shapes[0].Group)
+
+	painter.DeleteGroup{Name: "b"}.Do(&s, nil)
+	assert.Empty(t, s.Shapes)
+	require.Len(t, s.Figures, 1)
+	require.Len(t, s.Groups, 1)
+	assert.Equal(t, "a", s.Groups[0].Name)
+}
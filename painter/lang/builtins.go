@@ -0,0 +1,689 @@
+package lang
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Built-in commands of the painter language.
+func init() {
+	Default.MustRegister(Command{
+		Name: "white",
+		Help: "Set the background to white.",
+		New:  func(Values) (painter.Operation, error) { return painter.WhiteBg{}, nil },
+		Encode: func(op painter.Operation) (Values, bool) {
+			_, ok := op.(painter.WhiteBg)
+			return nil, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "green",
+		Help: "Set the background to green.",
+		New:  func(Values) (painter.Operation, error) { return painter.GreenBg{}, nil },
+		Encode: func(op painter.Operation) (Values, bool) {
+			_, ok := op.(painter.GreenBg)
+			return nil, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "bg-gradient",
+		Help: "Fill the background with a linear gradient in the direction of the angle (clockwise from the x axis) or a radial one from the center.",
+		Args: []Arg{
+			Choice("kind", painter.LinearGradient.String(), painter.RadialGradient.String()),
+			{Name: "from", Kind: Color}, {Name: "to", Kind: Color},
+			{Name: "angle", Kind: Number, Optional: true},
+		},
+		New: func(a Values) (painter.Operation, error) {
+			kind, _ := painter.ParseBgFillKind(a.String(0))
+			op := painter.BgFill{Kind: kind, From: a.Color(1), To: a.Color(2)}
+			if len(a) > 3 {
+				if kind != painter.LinearGradient {
+					return nil, &ArgError{Index: 3, Msg: "only linear gradients take an angle"}
+				}
+				op.Angle = a.Float(3)
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			f, ok := op.(painter.BgFill)
+			if !ok || !f.Kind.IsGradient() {
+				return nil, false
+			}
+			values := Values{f.Kind.String(), colorValue(f.From), colorValue(f.To)}
+			if f.Angle != 0 {
+				values = append(values, f.Angle)
+			}
+			return values, true
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "bg-pattern",
+		Help: "Fill the background with a checkerboard or stripes of two colors, size relative to the smaller window side (0.05 by default).",
+		Args: []Arg{
+			Choice("kind", painter.Checkerboard.String(), painter.Stripes.String()),
+			{Name: "color1", Kind: Color}, {Name: "color2", Kind: Color},
+			{Name: "size", Kind: Number, Bounded: true, Min: painter.MinPatternSize, Max: painter.MaxPatternSize, Optional: true},
+			{Name: "angle", Kind: Number, Optional: true},
+		},
+		New: func(a Values) (painter.Operation, error) {
+			kind, _ := painter.ParseBgFillKind(a.String(0))
+			op := painter.BgFill{Kind: kind, From: a.Color(1), To: a.Color(2)}
+			if len(a) > 3 {
+				op.Size = a.Float(3)
+			}
+			if len(a) > 4 {
+				if kind != painter.Stripes {
+					return nil, &ArgError{Index: 4, Msg: "only stripes take an angle"}
+				}
+				op.Angle = a.Float(4)
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			f, ok := op.(painter.BgFill)
+			if !ok || f.Kind.IsGradient() {
+				return nil, false
+			}
+			values := Values{f.Kind.String(), colorValue(f.From), colorValue(f.To)}
+			if f.Size != 0 || f.Angle != 0 {
+				if f.Size == 0 {
+					f.Size = painter.DefaultPatternSize // The angle goes after the size
+				}
+				values = append(values, f.Size)
+			}
+			if f.Angle != 0 {
+				values = append(values, f.Angle)
+			}
+			return values, true
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "bgrect",
+		Help: "Set the background rectangle by its corners, optionally translucent (opacity 1 by default).",
+		Args: []Arg{Coord("x1"), Coord("y1"), Coord("x2"), Coord("y2"), {Name: "opacity", Kind: Number, Bounded: true, Min: 0.01, Max: 1, Optional: true}},
+		New: func(a Values) (painter.Operation, error) {
+			op := painter.BgRect{X1: a.Float(0), Y1: a.Float(1), X2: a.Float(2), Y2: a.Float(3)}
+			if len(a) > 4 {
+				op.Opacity = a.Float(4)
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			r, ok := op.(painter.BgRect)
+			if r.Opacity != 0 {
+				return Values{r.X1, r.Y1, r.X2, r.Y2, r.Opacity}, ok
+			}
+			return Values{r.X1, r.Y1, r.X2, r.Y2}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "figure",
+		Help: "Add a figure centered at (x, y), size relative to the smaller window side (0.3 by default).",
+		Args: []Arg{Coord("x"), Coord("y"), {Name: "size", Kind: Number, Bounded: true, Min: painter.MinFigureSize, Max: painter.MaxFigureSize, Optional: true}},
+		New: func(a Values) (painter.Operation, error) {
+			op := painter.Figure{X: a.Float(0), Y: a.Float(1)}
+			if len(a) > 2 {
+				op.Size = a.Float(2)
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			f, ok := op.(painter.Figure)
+			if f.Size != 0 {
+				return Values{f.X, f.Y, f.Size}, ok
+			}
+			return Values{f.X, f.Y}, ok
+		},
+	})
+	shapeColor := Arg{Name: "color", Kind: Color, Optional: true, Default: painter.ColorHex(painter.DefaultShapeColor)}
+	Default.MustRegister(Command{
+		Name: "circle",
+		Help: "Add a circle centered at (x, y) with radius r relative to the smaller window side.",
+		Args: []Arg{Coord("x"), Coord("y"), Coord("r"), shapeColor},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Circle{X: a.Float(0), Y: a.Float(1), R: a.Float(2), Color: a.Color(3)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			c, ok := op.(painter.Circle)
+			return Values{c.X, c.Y, c.R, colorValue(c.Color)}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "ellipse",
+		Help: "Add an ellipse centered at (x, y) with radii relative to the window width and height.",
+		Args: []Arg{Coord("x"), Coord("y"), Coord("rx"), Coord("ry"), shapeColor},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Ellipse{X: a.Float(0), Y: a.Float(1), RX: a.Float(2), RY: a.Float(3), Color: a.Color(4)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			e, ok := op.(painter.Ellipse)
+			return Values{e.X, e.Y, e.RX, e.RY, colorValue(e.Color)}, ok
+		},
+	})
+	thickness := Arg{Name: "thickness", Kind: Number, Bounded: true, Min: 1, Max: 100, Optional: true, Default: "1"}
+	Default.MustRegister(Command{
+		Name: "line",
+		Help: "Add a line from (x1, y1) to (x2, y2), thickness in pixels.",
+		Args: []Arg{Coord("x1"), Coord("y1"), Coord("x2"), Coord("y2"), thickness, shapeColor},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Line{X1: a.Float(0), Y1: a.Float(1), X2: a.Float(2), Y2: a.Float(3), Thickness: a.Float(4), Color: a.Color(5)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			l, ok := op.(painter.Line)
+			return Values{l.X1, l.Y1, l.X2, l.Y2, max(l.Thickness, 1), colorValue(l.Color)}, ok
+		},
+	})
+	points := Coord("xy")
+	points.Variadic = true
+	Default.MustRegister(Command{
+		Name: "polygon",
+		Help: "Add a polygon with at least 3 vertices given as x y pairs.",
+		Args: []Arg{{Name: "color", Kind: Color}, points},
+		New: func(a Values) (painter.Operation, error) {
+			coords := a[1:]
+			if len(coords) < 6 || len(coords)%2 != 0 {
+				return nil, &ArgError{Index: len(a) - 1, Msg: "polygon needs at least 3 vertices as x y pairs"}
+			}
+			op := painter.Polygon{Color: a.Color(0)}
+			for i := 1; i < len(a); i += 2 {
+				op.Points = append(op.Points, [2]float64{a.Float(i), a.Float(i + 1)})
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			p, ok := op.(painter.Polygon)
+			values := Values{colorValue(p.Color)}
+			for _, pt := range p.Points {
+				values = append(values, pt[0], pt[1])
+			}
+			return values, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "text",
+		Help: `Add a text label with its top left corner at (x, y), e.g. text 0.1 0.1 "Hello, world" 24; size in pixels.`,
+		Args: []Arg{
+			Coord("x"), Coord("y"), {Name: "string", Kind: Text},
+			{Name: "size", Kind: Number, Bounded: true, Min: painter.MinTextSize, Max: painter.MaxTextSize, Optional: true, Default: strconv.Itoa(painter.DefaultTextSize)},
+			{Name: "color", Kind: Color, Optional: true, Default: painter.ColorHex(painter.DefaultTextColor)},
+		},
+		New: func(a Values) (painter.Operation, error) {
+			if a.String(2) == "" {
+				return nil, &ArgError{Index: 2, Msg: "text must not be empty"}
+			}
+			if n := utf8.RuneCountInString(a.String(2)); n > painter.MaxTextLength {
+				return nil, &ArgError{Index: 2, Msg: fmt.Sprintf("text of %d characters, expected at most %d", n, painter.MaxTextLength)}
+			}
+			return painter.Text{X: a.Float(0), Y: a.Float(1), Content: a.String(2), Size: a.Float(3), Color: a.Color(4)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			tx, ok := op.(painter.Text)
+			if tx.Size == 0 {
+				tx.Size = painter.DefaultTextSize
+			}
+			if tx.Color == nil {
+				tx.Color = painter.DefaultTextColor
+			}
+			return Values{tx.X, tx.Y, tx.Content, tx.Size, color.NRGBAModel.Convert(tx.Color).(color.NRGBA)}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "image",
+		Help: "Place an uploaded image with its top left corner at (x, y), in its own size or scaled to w x h.",
+		Args: []Arg{
+			{Name: "name", Kind: Word}, Coord("x"), Coord("y"),
+			{Name: "w", Kind: Number, Bounded: true, Min: 0.001, Max: 1, Optional: true},
+			{Name: "h", Kind: Number, Bounded: true, Min: 0.001, Max: 1, Optional: true},
+		},
+		New: func(a Values) (painter.Operation, error) {
+			if err := painter.ValidSpriteName(a.String(0)); err != nil {
+				return nil, &ArgError{Index: 0, Msg: err.Error()}
+			}
+			op := painter.Sprite{Name: a.String(0), X: a.Float(1), Y: a.Float(2)}
+			switch len(a) {
+			case 4:
+				return nil, &ArgError{Index: 3, Msg: "the width must be followed by a height"}
+			case 5:
+				op.W, op.H = a.Float(3), a.Float(4)
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sp, ok := op.(painter.Sprite)
+			if sp.W > 0 && sp.H > 0 {
+				return Values{sp.Name, sp.X, sp.Y, sp.W, sp.H}, ok
+			}
+			return Values{sp.Name, sp.X, sp.Y}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "layer",
+		Help: "Add new elements to the named layer, creating it on top of the others if it does not exist.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validLayer(a); err != nil {
+				return nil, err
+			}
+			return painter.Layer{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			l, ok := op.(painter.Layer)
+			return Values{l.Name}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "layer-move",
+		Help: "Move the layer to the top or bottom of the others, or one layer up or down.",
+		Args: []Arg{{Name: "name", Kind: Word}, Choice("to", "top", "bottom", "up", "down")},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validLayer(a); err != nil {
+				return nil, err
+			}
+			to, _ := painter.ParseLayerPosition(a.String(1))
+			return painter.MoveLayer{Name: a.String(0), To: to}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			m, ok := op.(painter.MoveLayer)
+			return Values{m.Name, m.To.String()}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "layer-hide",
+		Help: "Hide the layer without removing its elements.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validLayer(a); err != nil {
+				return nil, err
+			}
+			return painter.ShowLayer{Name: a.String(0), Visible: false}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sl, ok := op.(painter.ShowLayer)
+			return Values{sl.Name}, ok && !sl.Visible
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "layer-show",
+		Help: "Show a hidden layer.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validLayer(a); err != nil {
+				return nil, err
+			}
+			return painter.ShowLayer{Name: a.String(0), Visible: true}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sl, ok := op.(painter.ShowLayer)
+			return Values{sl.Name}, ok && sl.Visible
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "layer-clear",
+		Help: "Remove all elements of the layer, keeping the layer itself.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validLayer(a); err != nil {
+				return nil, err
+			}
+			return painter.ClearLayer{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			c, ok := op.(painter.ClearLayer)
+			return Values{c.Name}, ok
+		},
+	})
+	ids := ID("id")
+	ids.Variadic = true
+	Default.MustRegister(Command{
+		Name: "group",
+		Help: "Add the elements with the given ids to the named group, creating it if it does not exist.",
+		Args: []Arg{{Name: "name", Kind: Word}, ids},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			op := painter.Group{Name: a.String(0)}
+			for i := 1; i < len(a); i++ {
+				op.IDs = append(op.IDs, a.Int(i))
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			g, ok := op.(painter.Group)
+			values := Values{g.Name}
+			for _, id := range g.IDs {
+				values = append(values, id)
+			}
+			return values, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "ungroup",
+		Help: "Remove the group, keeping its elements in place.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.Ungroup{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			u, ok := op.(painter.Ungroup)
+			return Values{u.Name}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "group-move",
+		Help: "Shift the elements of the group by (dx, dy).",
+		Args: []Arg{{Name: "name", Kind: Word}, Num("dx"), Num("dy")},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.MoveGroup{Name: a.String(0), X: a.Float(1), Y: a.Float(2)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			m, ok := op.(painter.MoveGroup)
+			return Values{m.Name, m.X, m.Y}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "group-color",
+		Help: "Set the color of the figures, shapes and text labels of the group.",
+		Args: []Arg{{Name: "name", Kind: Word}, {Name: "color", Kind: Color}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.RecolorGroup{Name: a.String(0), Color: a.Color(1)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			r, ok := op.(painter.RecolorGroup)
+			return Values{r.Name, colorValue(r.Color)}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "group-hide",
+		Help: "Hide the elements of the group without removing them.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.ShowGroup{Name: a.String(0), Visible: false}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sg, ok := op.(painter.ShowGroup)
+			return Values{sg.Name}, ok && !sg.Visible
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "group-show",
+		Help: "Show the elements of a hidden group.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.ShowGroup{Name: a.String(0), Visible: true}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sg, ok := op.(painter.ShowGroup)
+			return Values{sg.Name}, ok && sg.Visible
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "group-delete",
+		Help: "Remove the group together with all its elements.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := validGroup(a); err != nil {
+				return nil, err
+			}
+			return painter.DeleteGroup{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			d, ok := op.(painter.DeleteGroup)
+			return Values{d.Name}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "move",
+		Help: "Shift all figures by (dx, dy).",
+		// Note: move offsets can theoretically be outside 0-1 range
+		Args: []Arg{Num("dx"), Num("dy")},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Move{X: a.Float(0), Y: a.Float(1)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			m, ok := op.(painter.Move)
+			return Values{m.X, m.Y}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "rotate",
+		Help: "Rotate the figure with the given id clockwise to the absolute angle in degrees.",
+		Args: []Arg{ID("id"), Num("degrees")},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Rotate{ID: a.Int(0), Angle: a.Float(1)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			r, ok := op.(painter.Rotate)
+			return Values{r.ID, r.Angle}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "scale",
+		Help: "Multiply the size of the figure with the given id by the factor.",
+		Args: []Arg{ID("id"), {Name: "factor", Kind: Number, Bounded: true, Min: 0.01, Max: 100}},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Scale{ID: a.Int(0), Factor: a.Float(1)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			sc, ok := op.(painter.Scale)
+			return Values{sc.ID, sc.Factor}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "opacity",
+		Help: "Set the opacity of the figure or shape with the given id, from 0 (invisible) to 1 (opaque).",
+		Args: []Arg{ID("id"), {Name: "value", Kind: Number, Bounded: true, Min: 0, Max: 1}},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Opacity{ID: a.Int(0), Value: a.Float(1)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			o, ok := op.(painter.Opacity)
+			return Values{o.ID, o.Value}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "reset",
+		Help: "Clear the state to defaults.",
+		New:  func(Values) (painter.Operation, error) { return painter.Reset{}, nil },
+		Encode: func(op painter.Operation) (Values, bool) {
+			_, ok := op.(painter.Reset)
+			return nil, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "wait",
+		Help: "Delay the rest of the batch.",
+		Args: []Arg{Dur("duration")},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Wait{Duration: a.Duration(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			w, ok := op.(painter.Wait)
+			return Values{w.Duration}, ok
+		},
+	})
+	easing := Choice("easing", easingNames()...)
+	easing.Optional, easing.Default = true, "linear"
+	Default.MustRegister(Command{
+		Name: "animate-move",
+		Help: "Smoothly shift all figures by (dx, dy) over the duration.",
+		Args: []Arg{Num("dx"), Num("dy"), Dur("duration"), easing},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.AnimateMove{X: a.Float(0), Y: a.Float(1), Duration: a.Duration(2), Easing: a.String(3)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			m, ok := op.(painter.AnimateMove)
+			if m.Easing == "" {
+				m.Easing = "linear" // An empty name means linear easing
+			}
+			return Values{m.X, m.Y, m.Duration, m.Easing}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "save",
+		Help: "Save the current state as a named scene.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := painter.ValidSceneName(a.String(0)); err != nil {
+				return nil, &ArgError{Index: 0, Msg: err.Error()}
+			}
+			return painter.Save{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			s, ok := op.(painter.Save)
+			return Values{s.Name}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "load",
+		Help: "Replace the state with a saved scene.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := painter.ValidSceneName(a.String(0)); err != nil {
+				return nil, &ArgError{Index: 0, Msg: err.Error()}
+			}
+			return painter.Load{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			l, ok := op.(painter.Load)
+			return Values{l.Name}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "export-svg",
+		Help: "Write the current state as SVG to a named file in the export directory.",
+		Args: []Arg{{Name: "name", Kind: Word}},
+		New: func(a Values) (painter.Operation, error) {
+			if err := painter.ValidSceneName(a.String(0)); err != nil {
+				return nil, &ArgError{Index: 0, Msg: err.Error()}
+			}
+			return painter.ExportSVG{Name: a.String(0)}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			e, ok := op.(painter.ExportSVG)
+			return Values{e.Name}, ok && e.Writer == nil && e.Result == nil
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "record",
+		Help: "Start capturing frames to a GIF or PNG sequence in the export directory, optionally for a duration, or stop and write them.",
+		Args: []Arg{
+			Choice("action", "start", "stop"),
+			{Name: "name", Kind: Word, Optional: true},
+			{Name: "format", Kind: Word, Choices: []string{painter.CaptureGIF, painter.CapturePNG}, Optional: true, Default: painter.CaptureGIF},
+			{Name: "duration", Kind: Duration, Optional: true},
+		},
+		New: func(a Values) (painter.Operation, error) {
+			if a.String(0) == "stop" {
+				if len(a) > 1 {
+					return nil, &ArgError{Index: 1, Msg: "record stop takes no arguments"}
+				}
+				return painter.RecordStop{}, nil
+			}
+			if len(a) < 2 {
+				return nil, &ArgError{Index: 1, Msg: "record start needs a name"}
+			}
+			if err := painter.ValidSceneName(a.String(1)); err != nil {
+				return nil, &ArgError{Index: 1, Msg: err.Error()}
+			}
+			op := painter.RecordStart{Name: a.String(1), Format: a.String(2)}
+			if len(a) > 3 {
+				op.Duration = a.Duration(3)
+				if err := painter.ValidCaptureDuration(op.Duration); err != nil {
+					return nil, &ArgError{Index: 3, Msg: err.Error()}
+				}
+			}
+			return op, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			switch r := op.(type) {
+			case painter.RecordStop:
+				return Values{"stop"}, r.Result == nil
+			case painter.RecordStart:
+				if r.Format == "" {
+					r.Format = painter.CaptureGIF
+				}
+				if r.Duration > 0 {
+					return Values{"start", r.Name, r.Format, r.Duration}, true
+				}
+				return Values{"start", r.Name, r.Format}, true
+			}
+			return nil, false
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "antialias",
+		Help: "Switch anti-aliased rendering of rotated figures and shapes on or off.",
+		Args: []Arg{Choice("mode", "on", "off")},
+		New: func(a Values) (painter.Operation, error) {
+			return painter.Antialias{Enabled: a.String(0) == "on"}, nil
+		},
+		Encode: func(op painter.Operation) (Values, bool) {
+			aa, ok := op.(painter.Antialias)
+			if aa.Enabled {
+				return Values{"on"}, ok
+			}
+			return Values{"off"}, ok
+		},
+	})
+	Default.MustRegister(Command{
+		Name: "update",
+		Help: "Redraw the scene and show it.",
+		New:  func(Values) (painter.Operation, error) { return painter.UpdateOp{}, nil },
+		Encode: func(op painter.Operation) (Values, bool) {
+			_, ok := op.(painter.UpdateOp)
+			return nil, ok
+		},
+	})
+}
+
+// colorValue converts an operation color to an argument value,
+// using the default shape color for nil.
+func colorValue(c color.Color) color.NRGBA {
+	if c == nil {
+		c = painter.DefaultShapeColor
+	}
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
+
+// validLayer checks the layer name, which is the first argument of the layer commands.
+func validLayer(a Values) error {
+	if err := painter.ValidLayerName(a.String(0)); err != nil {
+		return &ArgError{Index: 0, Msg: err.Error()}
+	}
+	return nil
+}
+
+// validGroup checks the group name, which is the first argument of the group commands.
+func validGroup(a Values) error {
+	if err := painter.ValidGroupName(a.String(0)); err != nil {
+		return &ArgError{Index: 0, Msg: err.Error()}
+	}
+	return nil
+}
+
+// easingNames returns the names of painter.Easings in a stable order.
+func easingNames() []string {
+	return slices.Sorted(maps.Keys(painter.Easings))
+}
@@ -0,0 +1,290 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// JSONError describes an invalid operation in a JSON document.
+type JSONError struct {
+	Index int    // 0-based index of the operation in the array
+	Field string // Offending field, empty if the whole operation is invalid
+	Msg   string
+}
+
+func (e *JSONError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("operation %d, field %q: %s", e.Index, e.Field, e.Msg)
+	}
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Msg)
+}
+
+// EncodeText encodes operations with the default registry. See Registry.EncodeText.
+func EncodeText(ops []painter.Operation) ([]byte, error) {
+	return Default.EncodeText(ops)
+}
+
+// EncodeJSON encodes operations with the default registry. See Registry.EncodeJSON.
+func EncodeJSON(ops []painter.Operation) ([]byte, error) {
+	return Default.EncodeJSON(ops)
+}
+
+// DecodeJSON decodes operations with the default registry. See Registry.DecodeJSON.
+func DecodeJSON(data []byte) ([]painter.Operation, error) {
+	return Default.DecodeJSON(data)
+}
+
+// encoder finds the command that encodes op.
+func (r *Registry) encoder(op painter.Operation) (*Command, Values, error) {
+	for _, name := range r.Names() {
+		cmd, _ := r.Lookup(name)
+		if cmd.Encode == nil {
+			continue
+		}
+		if values, ok := cmd.Encode(op); ok {
+			return cmd, values, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no command encodes operation %T", op)
+}
+
+// flatten expands nested operation lists into a single sequence.
+func flatten(ops []painter.Operation) []painter.Operation {
+	var flat []painter.Operation
+	for _, op := range ops {
+		if list, ok := op.(painter.OperationList); ok {
+			flat = append(flat, flatten(list)...)
+		} else {
+			flat = append(flat, op)
+		}
+	}
+	return flat
+}
+
+// Format encodes a single operation as a command line accepted by Parse.
+func (r *Registry) Format(op painter.Operation) (string, error) {
+	cmd, values, err := r.encoder(op)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{cmd.Name}
+	for i, v := range values {
+		spec, _ := cmd.spec(i)
+		parts = append(parts, spec.format(v))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// EncodeText encodes operations in the line-based text format, one command
+// per line. Operation lists are flattened.
+func (r *Registry) EncodeText(ops []painter.Operation) ([]byte, error) {
+	var b bytes.Buffer
+	for _, op := range flatten(ops) {
+		line, err := r.Format(op)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// EncodeJSON encodes operations as a JSON array of objects. Every object has
+// an "op" field with the command name and a field per argument, e.g.
+// [{"op":"figure","x":0.5,"y":0.5}]. Operation lists are flattened.
+func (r *Registry) EncodeJSON(ops []painter.Operation) ([]byte, error) {
+	objs := []map[string]any{}
+	for _, op := range flatten(ops) {
+		cmd, values, err := r.encoder(op)
+		if err != nil {
+			return nil, err
+		}
+		obj := map[string]any{"op": cmd.Name}
+		for i, v := range values {
+			spec, _ := cmd.spec(i)
+			if spec.Variadic {
+				// All values of a variadic argument form a JSON array
+				list, _ := obj[spec.Name].([]any)
+				obj[spec.Name] = append(list, jsonValue(v))
+			} else {
+				obj[spec.Name] = jsonValue(v)
+			}
+		}
+		objs = append(objs, obj)
+	}
+	return json.Marshal(objs)
+}
+
+// DecodeJSON decodes a JSON array produced by EncodeJSON. Arguments are
+// validated exactly like in the text format; errors are returned as *JSONError.
+func (r *Registry) DecodeJSON(data []byte) ([]painter.Operation, error) {
+	var objs []map[string]any
+	if err := json.Unmarshal(data, &objs); err != nil {
+		return nil, fmt.Errorf("invalid JSON operations: %w", err)
+	}
+
+	ops := make([]painter.Operation, 0, len(objs))
+	for i, obj := range objs {
+		name, ok := obj["op"].(string)
+		if !ok {
+			return nil, &JSONError{Index: i, Field: "op", Msg: "missing command name"}
+		}
+		cmd, ok := r.Lookup(name)
+		if !ok {
+			msg := "unknown command " + strconv.Quote(name)
+			if s := suggest(name, r.Names()); s != "" {
+				msg += fmt.Sprintf("; did you mean %q?", s)
+			}
+			return nil, &JSONError{Index: i, Field: "op", Msg: msg}
+		}
+		for field := range obj {
+			if field != "op" && !cmd.hasArg(field) {
+				return nil, &JSONError{Index: i, Field: field, Msg: "unexpected argument; expected: " + cmd.Usage()}
+			}
+		}
+
+		values := make(Values, 0, len(cmd.Args))
+		for n, spec := range cmd.Args {
+			raw, present := obj[spec.Name]
+			if spec.Variadic {
+				vals, err := decodeVariadic(spec, raw, present)
+				if err != nil {
+					return nil, &JSONError{Index: i, Field: spec.Name, Msg: err.Error() + "; expected: " + cmd.Usage()}
+				}
+				values = append(values, vals...)
+				break
+			}
+			text := spec.Default
+			switch v := raw.(type) {
+			case float64:
+				text = strconv.FormatFloat(v, 'g', -1, 64)
+			case string:
+				// An empty string means the default, like an omitted argument;
+				// it must not end the arguments early
+				if v != "" {
+					text = v
+				}
+			case nil:
+				if present {
+					return nil, &JSONError{Index: i, Field: spec.Name, Msg: "null argument"}
+				}
+			default:
+				return nil, &JSONError{Index: i, Field: spec.Name, Msg: "argument must be a number or a string"}
+			}
+			if text == "" {
+				if !spec.Optional {
+					return nil, &JSONError{Index: i, Field: spec.Name, Msg: "missing argument; expected: " + cmd.Usage()}
+				}
+				// Аргументи позиційні, тож наступні не можна задати без цього
+				for _, later := range cmd.Args[n+1:] {
+					if v, ok := obj[later.Name]; ok && v != "" {
+						return nil, &JSONError{Index: i, Field: later.Name, Msg: fmt.Sprintf("argument needs %q to be set; expected: %s", spec.Name, cmd.Usage())}
+					}
+				}
+				break
+			}
+			val, msg := spec.convert(text)
+			if msg != "" {
+				return nil, &JSONError{Index: i, Field: spec.Name, Msg: msg}
+			}
+			values = append(values, val)
+		}
+
+		op, err := cmd.build(values)
+		if err != nil {
+			var argErr *ArgError
+			if errors.As(err, &argErr) {
+				if spec, ok := cmd.spec(argErr.Index); ok {
+					return nil, &JSONError{Index: i, Field: spec.Name, Msg: err.Error()}
+				}
+			}
+			return nil, &JSONError{Index: i, Msg: err.Error()}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// hasArg reports whether the command declares an argument with the given name.
+func (c *Command) hasArg(name string) bool {
+	for _, a := range c.Args {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeVariadic converts the JSON array of a variadic argument.
+func decodeVariadic(spec Arg, raw any, present bool) (Values, error) {
+	if !present {
+		if spec.Optional {
+			return nil, nil
+		}
+		return nil, errors.New("missing argument")
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("argument must be an array")
+	}
+	if len(list) == 0 && !spec.Optional {
+		return nil, errors.New("at least one value is required")
+	}
+	values := make(Values, 0, len(list))
+	for _, item := range list {
+		var text string
+		switch v := item.(type) {
+		case float64:
+			text = strconv.FormatFloat(v, 'g', -1, 64)
+		case string:
+			text = v
+		default:
+			return nil, errors.New("array elements must be numbers or strings")
+		}
+		val, msg := spec.convert(text)
+		if msg != "" {
+			return nil, errors.New(msg)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// jsonValue converts an argument value to its JSON representation.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case time.Duration:
+		return v.String()
+	case color.NRGBA:
+		return painter.ColorHex(v)
+	}
+	return v
+}
+
+// format renders an argument value in the text format.
+func (a Arg) format(v any) string {
+	if s, ok := v.(string); ok && a.Kind == Text {
+		return strconv.Quote(s)
+	}
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case time.Duration:
+		return v.String()
+	case color.NRGBA:
+		return painter.ColorHex(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
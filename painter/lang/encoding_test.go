@@ -0,0 +1,223 @@
+package lang_test
+
+import (
+	"errors"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+var roundTripOps = []painter.Operation{
+	painter.Reset{},
+	painter.WhiteBg{},
+	painter.BgRect{X1: 0.1, Y1: 0.2, X2: 0.8, Y2: 0.9},
+	painter.OperationList{
+		painter.Figure{X: 0.5, Y: 0.5},
+		painter.Wait{Duration: 1500 * time.Millisecond},
+	},
+	painter.AnimateMove{X: 0.25, Y: -0.1, Duration: time.Second, Easing: "ease-out"},
+	painter.RecordStart{Name: "demo", Format: "png", Duration: 2 * time.Second},
+	painter.RecordStop{},
+	painter.Rotate{ID: 3, Angle: 22.5},
+	painter.Figure{X: 0.1, Y: 0.2, Size: 0.05},
+	painter.Scale{ID: 4, Factor: 0.5},
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.1, Color: color.NRGBA{B: 0xff, A: 0xff}},
+	painter.Ellipse{X: 0.5, Y: 0.4, RX: 0.2, RY: 0.1, Color: color.NRGBA{R: 0xff, A: 0x80}},
+	painter.Line{X1: 0, Y1: 0, X2: 1, Y2: 1, Thickness: 3, Color: color.NRGBA{G: 0xff, A: 0xff}},
+	painter.Polygon{Points: [][2]float64{{0.1, 0.1}, {0.9, 0.1}, {0.5, 0.9}}, Color: color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}},
+	painter.Antialias{Enabled: true},
+	painter.BgRect{X1: 0.2, Y1: 0.2, X2: 0.4, Y2: 0.4, Opacity: 0.5},
+	painter.Opacity{ID: 2, Value: 0.75},
+	painter.Text{X: 0.1, Y: 0.9, Content: `say "hi" #1`, Size: 20, Color: color.NRGBA{B: 0xff, A: 0xff}},
+	painter.BgFill{Kind: painter.LinearGradient, From: color.NRGBA{R: 0xff, A: 0xff}, To: color.NRGBA{B: 0xff, A: 0xff}, Angle: 45},
+	painter.BgFill{Kind: painter.Checkerboard, From: color.NRGBA{A: 0xff}, To: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	painter.BgFill{Kind: painter.Stripes, From: color.NRGBA{A: 0xff}, To: color.NRGBA{G: 0xff, A: 0xff}, Size: 0.1, Angle: 30},
+	painter.Sprite{Name: "logo", X: 0.8, Y: 0.05},
+	painter.Sprite{Name: "icon", X: 0.1, Y: 0.1, W: 0.2, H: 0.15},
+	painter.Layer{Name: "overlay"},
+	painter.MoveLayer{Name: "overlay", To: painter.LayerDown},
+	painter.ShowLayer{Name: "overlay", Visible: false},
+	painter.ShowLayer{Name: "overlay", Visible: true},
+	painter.ClearLayer{Name: "base"},
+	painter.Group{Name: "logo", IDs: []int{1, 2, 5}},
+	painter.MoveGroup{Name: "logo", X: -0.1, Y: 0.25},
+	painter.RecolorGroup{Name: "logo", Color: color.NRGBA{R: 0xff, G: 0x80, A: 0xff}},
+	painter.ShowGroup{Name: "logo", Visible: false},
+	painter.ShowGroup{Name: "logo", Visible: true},
+	painter.Ungroup{Name: "logo"},
+	painter.DeleteGroup{Name: "logo"},
+	painter.UpdateOp{},
+}
+
+// flatRoundTripOps is roundTripOps as it comes back after decoding.
+var flatRoundTripOps = []painter.Operation{
+	painter.Reset{},
+	painter.WhiteBg{},
+	painter.BgRect{X1: 0.1, Y1: 0.2, X2: 0.8, Y2: 0.9},
+	painter.Figure{X: 0.5, Y: 0.5},
+	painter.Wait{Duration: 1500 * time.Millisecond},
+	painter.AnimateMove{X: 0.25, Y: -0.1, Duration: time.Second, Easing: "ease-out"},
+	painter.RecordStart{Name: "demo", Format: "png", Duration: 2 * time.Second},
+	painter.RecordStop{},
+	painter.Rotate{ID: 3, Angle: 22.5},
+	painter.Figure{X: 0.1, Y: 0.2, Size: 0.05},
+	painter.Scale{ID: 4, Factor: 0.5},
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.1, Color: color.NRGBA{B: 0xff, A: 0xff}},
+	painter.Ellipse{X: 0.5, Y: 0.4, RX: 0.2, RY: 0.1, Color: color.NRGBA{R: 0xff, A: 0x80}},
+	painter.Line{X1: 0, Y1: 0, X2: 1, Y2: 1, Thickness: 3, Color: color.NRGBA{G: 0xff, A: 0xff}},
+	painter.Polygon{Points: [][2]float64{{0.1, 0.1}, {0.9, 0.1}, {0.5, 0.9}}, Color: color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}},
+	painter.Antialias{Enabled: true},
+	painter.BgRect{X1: 0.2, Y1: 0.2, X2: 0.4, Y2: 0.4, Opacity: 0.5},
+	painter.Opacity{ID: 2, Value: 0.75},
+	painter.Text{X: 0.1, Y: 0.9, Content: `say "hi" #1`, Size: 20, Color: color.NRGBA{B: 0xff, A: 0xff}},
+	painter.BgFill{Kind: painter.LinearGradient, From: color.NRGBA{R: 0xff, A: 0xff}, To: color.NRGBA{B: 0xff, A: 0xff}, Angle: 45},
+	painter.BgFill{Kind: painter.Checkerboard, From: color.NRGBA{A: 0xff}, To: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	painter.BgFill{Kind: painter.Stripes, From: color.NRGBA{A: 0xff}, To: color.NRGBA{G: 0xff, A: 0xff}, Size: 0.1, Angle: 30},
+	painter.Sprite{Name: "logo", X: 0.8, Y: 0.05},
+	painter.Sprite{Name: "icon", X: 0.1, Y: 0.1, W: 0.2, H: 0.15},
+	painter.Layer{Name: "overlay"},
+	painter.MoveLayer{Name: "overlay", To: painter.LayerDown},
+	painter.ShowLayer{Name: "overlay", Visible: false},
+	painter.ShowLayer{Name: "overlay", Visible: true},
+	painter.ClearLayer{Name: "base"},
+	painter.Group{Name: "logo", IDs: []int{1, 2, 5}},
+	painter.MoveGroup{Name: "logo", X: -0.1, Y: 0.25},
+	painter.RecolorGroup{Name: "logo", Color: color.NRGBA{R: 0xff, G: 0x80, A: 0xff}},
+	painter.ShowGroup{Name: "logo", Visible: false},
+	painter.ShowGroup{Name: "logo", Visible: true},
+	painter.Ungroup{Name: "logo"},
+	painter.DeleteGroup{Name: "logo"},
+	painter.UpdateOp{},
+}
+
+func TestEncodeJSON_RoundTrip(t *testing.T) {
+	data, err := lang.EncodeJSON(roundTripOps)
+	if err != nil {
+		t.Fatalf("EncodeJSON failed: %v", err)
+	}
+	if !strings.Contains(string(data), `{"op":"figure","x":0.5,"y":0.5}`) ||
+		!strings.Contains(string(data), `{"color":"#102030","op":"polygon","xy":[0.1,0.1,0.9,0.1,0.5,0.9]}`) {
+		t.Errorf("EncodeJSON produced unexpected document: %s", data)
+	}
+	ops, err := lang.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("DecodeJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(ops, flatRoundTripOps) {
+		t.Errorf("JSON round trip expected %+v, got %+v", flatRoundTripOps, ops)
+	}
+}
+
+func TestEncodeText_RoundTrip(t *testing.T) {
+	text, err := lang.EncodeText(roundTripOps)
+	if err != nil {
+		t.Fatalf("EncodeText failed: %v", err)
+	}
+	ops, err := lang.ParseCommands(strings.NewReader(string(text)))
+	if err != nil {
+		t.Fatalf("ParseCommands failed on encoded text:\n%s\n%v", text, err)
+	}
+	if !reflect.DeepEqual(ops, flatRoundTripOps) {
+		t.Errorf("text round trip expected %+v, got %+v", flatRoundTripOps, ops)
+	}
+}
+
+func TestEncodeJSON_DefaultEasing(t *testing.T) {
+	data, err := lang.EncodeJSON([]painter.Operation{painter.AnimateMove{X: 0.1, Y: 0, Duration: time.Second}})
+	if err != nil {
+		t.Fatalf("EncodeJSON failed: %v", err)
+	}
+	ops, err := lang.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("DecodeJSON failed: %v", err)
+	}
+	expected := []painter.Operation{painter.AnimateMove{X: 0.1, Y: 0, Duration: time.Second, Easing: "linear"}}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ops)
+	}
+
+	// Порожній рядок означає значення за замовчуванням і не обриває аргументи
+	ops, err = lang.DecodeJSON([]byte(`[{"op":"text","x":0.1,"y":0.1,"string":"hi","size":"","color":"#ff0000"}]`))
+	if err != nil {
+		t.Fatalf("DecodeJSON failed: %v", err)
+	}
+	expected = []painter.Operation{painter.Text{X: 0.1, Y: 0.1, Content: "hi", Size: painter.DefaultTextSize, Color: color.NRGBA{R: 0xff, A: 0xff}}}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ops)
+	}
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		field    string
+	}{
+		{"unknown command", `[{"op":"figur","x":0.5,"y":0.5}]`, "op"},
+		{"missing argument", `[{"op":"figure","x":0.5}]`, "y"},
+		{"out of range", `[{"op":"figure","x":0.5,"y":1.5}]`, "y"},
+		{"unexpected field", `[{"op":"white","color":"red"}]`, "color"},
+		{"wrong type", `[{"op":"wait","duration":true}]`, "duration"},
+		{"argument after missing optional", `[{"op":"image","name":"a","x":0.1,"y":0.1,"h":0.5}]`, "h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lang.DecodeJSON([]byte(tt.document))
+			var jsonErr *lang.JSONError
+			if !errors.As(err, &jsonErr) {
+				t.Fatalf("DecodeJSON(%s) expected *lang.JSONError, got %v", tt.document, err)
+			}
+			if jsonErr.Field != tt.field {
+				t.Errorf("DecodeJSON(%s) blamed field %q, expected %q", tt.document, jsonErr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestHttpHandler_ContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		expected    painter.Operation
+	}{
+		{"text body", "text/plain", "white\nfigure 0.5 0.5\n", http.StatusOK,
+			painter.OperationList{painter.WhiteBg{}, painter.Figure{X: 0.5, Y: 0.5}}},
+		{"json body", "application/json; charset=utf-8", `[{"op":"white"},{"op":"figure","x":0.5,"y":0.5}]`, http.StatusOK,
+			painter.OperationList{painter.WhiteBg{}, painter.Figure{X: 0.5, Y: 0.5}}},
+		{"invalid text", "text/plain", "white\nfigur 0.5 0.5\n", http.StatusBadRequest, nil},
+		{"invalid json", "application/json", `{"op":"white"`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loop := painter.NewLoop(nil, 800, 800)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			lang.HttpHandler(loop).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			posted := loop.Mq.Pull()
+			if tt.expected == nil {
+				if len(posted) != 0 {
+					t.Errorf("expected nothing posted, got %+v", posted)
+				}
+				return
+			}
+			if len(posted) != 1 || !reflect.DeepEqual(posted[0], tt.expected) {
+				t.Errorf("expected %+v posted, got %+v", tt.expected, posted)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError describes a problem found while parsing a command line.
+type SyntaxError struct {
+	Line       int    // 1-based line number in the script, 0 when parsing a single line
+	Column     int    // 1-based column of the offending token
+	Token      string // The offending token, empty if an argument is missing
+	Msg        string // What went wrong
+	Expected   string // Usage of the command, e.g. "figure x y"
+	Suggestion string // Closest known command for an unknown one
+}
+
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d, ", e.Line)
+	}
+	fmt.Fprintf(&b, "column %d: %s", e.Column, e.Msg)
+	if e.Token != "" {
+		fmt.Fprintf(&b, " %q", e.Token)
+	}
+	if e.Suggestion != "" {
+		fmt.Fprintf(&b, "; did you mean %q?", e.Suggestion)
+	}
+	if e.Expected != "" {
+		fmt.Fprintf(&b, "; expected: %s", e.Expected)
+	}
+	return b.String()
+}
+
+// ErrorList is a list of syntax errors found in a script.
+type ErrorList []*SyntaxError
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap allows errors.As to find the individual *SyntaxError values.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+	return errs
+}
+
+// suggest returns the known name closest to word, or "" if none is close enough.
+func suggest(word string, known []string) string {
+	best, bestDist := "", len(word)/2+1
+	for _, k := range known {
+		if d := distance(word, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// distance computes the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,85 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// FrameEvent is the payload of an SSE "frame" event.
+type FrameEvent struct {
+	Frame uint64                     `json:"frame"`
+	Diff  map[string]json.RawMessage `json:"diff"` // Top-level state fields changed since the previous event
+}
+
+// EventsHandler streams Server-Sent Events with a "frame" event every time
+// the loop sends an update to its Receiver. The first event of a stream
+// carries the full state, the following ones only the changed fields.
+func EventsHandler(loop *painter.Loop) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		frames, cancel := loop.Subscribe()
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		log.Printf("Events: Client %s subscribed", r.RemoteAddr)
+
+		var prev map[string]json.RawMessage
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Printf("Events: Client %s unsubscribed", r.RemoteAddr)
+				return
+			case f := <-frames:
+				cur, err := stateFields(f.State)
+				if err != nil {
+					log.Printf("Events: Error encoding state: %v", err)
+					return
+				}
+				data, err := json.Marshal(FrameEvent{Frame: f.Number, Diff: diffFields(prev, cur)})
+				if err != nil {
+					log.Printf("Events: Error encoding event: %v", err)
+					return
+				}
+				prev = cur
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: frame\ndata: %s\n\n", f.Number, data); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// stateFields splits the JSON form of the snapshot into its top-level fields.
+func stateFields(s painter.Snapshot) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// diffFields returns the fields of cur that differ from prev.
+func diffFields(prev, cur map[string]json.RawMessage) map[string]json.RawMessage {
+	diff := make(map[string]json.RawMessage)
+	for k, v := range cur {
+		if old, ok := prev[k]; !ok || !bytes.Equal(old, v) {
+			diff[k] = v
+		}
+	}
+	return diff
+}
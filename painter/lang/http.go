@@ -1,61 +1,58 @@
 package lang
 
 import (
-	"bufio"
+	"io"
 	"log"
+	"mime"
 	"net/http"
 
 	"github.com/roman-mazur/architecture-lab-3/painter" // Adjust import path
 )
 
 // HttpHandler creates an HTTP handler that parses commands and posts them to the loop.
+// The body is either the line-based text format or, if the Content-Type is
+// application/json, a JSON array of operations (see EncodeJSON).
+// If any command is invalid, nothing is posted and the response is
+// 400 Bad Request with the diagnostics.
 func HttpHandler(loop *painter.Loop) http.HandlerFunc {
+	session := &Session{Loop: loop}
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			log.Printf("HTTP Handler: Method not allowed %s", r.Method)
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-
-		scanner := bufio.NewScanner(r.Body)
 		defer r.Body.Close()
 
-		var ops []painter.Operation // Collect operations from the request
-
-		for scanner.Scan() {
-			commandLine := scanner.Text()
-			log.Printf("HTTP Handler: Received command: %s", commandLine) // Log received command
-
-			op, err := Parse(commandLine)
-			if err != nil {
-				log.Printf("HTTP Handler: Error parsing command '%s': %v", commandLine, err)
-				// Decide whether to stop processing or just skip the bad line
-				// http.Error(w, "Error parsing command: "+err.Error(), http.StatusBadRequest)
-				// return
-				continue // Skip this line and process others
-			}
-			if op != nil {
-				ops = append(ops, op)
-			}
-		}
-
-		if err := scanner.Err(); err != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
 			log.Printf("HTTP Handler: Error reading request body: %v", err)
 			http.Error(w, "Error reading request body", http.StatusInternalServerError)
 			return
 		}
 
-		// Post all parsed operations to the loop
-		// Note: Posting as a single list might be better with OperationList
-		// loop.Post(painter.OperationList(ops)) // If OperationList is implemented
-		// Or post one by one:
-		for _, op := range ops {
-			loop.Post(op)
+		var n int
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+			n, err = session.SubmitJSON(body)
+		} else {
+			n, err = session.SubmitText(body)
+		}
+		if err != nil {
+			log.Printf("HTTP Handler: Rejecting commands:\n%v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		log.Printf("HTTP Handler: Successfully processed %d operations", len(ops))
+		log.Printf("HTTP Handler: Successfully processed %d operations", n)
 		w.WriteHeader(http.StatusOK) // Send OK response
 		w.Write([]byte("Commands processed\n"))
+	}
+}
 
+// HelpHandler serves the description of the commands known to the registry.
+func HelpHandler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(reg.Help()))
 	}
 }
@@ -0,0 +1,139 @@
+package lang
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// JournalEntry is a group of batches pulled by the loop at once.
+type JournalEntry struct {
+	At      time.Duration           // Time since the start of the journal
+	Batches []painter.OperationList // Batches in the order they were posted
+}
+
+// journalLine is the on-disk form of a JournalEntry: one JSON object per line,
+// e.g. {"at":"1.5s","batches":[[{"op":"figure","x":0.5,"y":0.5},{"op":"update"}]]}.
+type journalLine struct {
+	At      string            `json:"at"`
+	Batches []json.RawMessage `json:"batches"`
+}
+
+// JournalWriter implements painter.Journal by writing entries as JSON lines.
+// Operations that cannot be encoded by the registry are left out, logged and
+// counted (see Skipped); the rest of their batch is still recorded.
+type JournalWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	start    time.Time
+	skipped  int
+	Registry *Registry // Registry used to encode operations, Default if nil
+}
+
+// NewJournalWriter creates a journal writing to w. Timestamps are relative to the call.
+func NewJournalWriter(w io.Writer) *JournalWriter {
+	return &JournalWriter{w: w, start: time.Now()}
+}
+
+// Record implements painter.Journal.
+func (j *JournalWriter) Record(at time.Time, ops []painter.Operation) {
+	reg := j.Registry
+	if reg == nil {
+		reg = Default
+	}
+	line := journalLine{At: at.Sub(j.start).String()}
+	skipped := 0
+	for _, op := range ops {
+		var batch []painter.Operation
+		for _, o := range flatten([]painter.Operation{op}) {
+			if _, _, err := reg.encoder(o); err != nil {
+				log.Printf("Journal: Skipping operation: %v", err)
+				skipped++
+				continue
+			}
+			batch = append(batch, o)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		data, err := reg.EncodeJSON(batch)
+		if err != nil {
+			log.Printf("Journal: Skipping batch of %d operations: %v", len(batch), err)
+			skipped += len(batch)
+			continue
+		}
+		line.Batches = append(line.Batches, data)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.skipped += skipped
+	if len(line.Batches) == 0 {
+		return
+	}
+	if err := json.NewEncoder(j.w).Encode(line); err != nil {
+		log.Printf("Journal: Error writing entry: %v", err)
+	}
+}
+
+// Skipped returns the number of operations left out of the journal because
+// they could not be encoded.
+func (j *JournalWriter) Skipped() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.skipped
+}
+
+// ReadJournal reads all entries of a journal written by JournalWriter.
+func ReadJournal(r io.Reader) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var line journalLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("journal line %d: %w", n, err)
+		}
+		at, err := time.ParseDuration(line.At)
+		if err != nil {
+			return nil, fmt.Errorf("journal line %d: invalid time: %w", n, err)
+		}
+		entry := JournalEntry{At: at}
+		for _, data := range line.Batches {
+			ops, err := DecodeJSON(data)
+			if err != nil {
+				return nil, fmt.Errorf("journal line %d: %w", n, err)
+			}
+			entry.Batches = append(entry.Batches, painter.OperationList(ops))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Replay posts the journal entries to the loop, keeping their original
+// timing divided by speed (1 is the original speed). Batches of an entry
+// are posted together, so they are processed together as when recorded.
+// The loop's Speed should be set to the same value to scale waits and
+// animations. Replay returns once the loop is idle after the last entry.
+func Replay(loop *painter.Loop, entries []JournalEntry, speed float64) {
+	if speed <= 0 {
+		speed = 1
+	}
+	start := time.Now()
+	for _, e := range entries {
+		time.Sleep(time.Until(start.Add(time.Duration(float64(e.At) / speed))))
+		ops := make([]painter.Operation, len(e.Batches))
+		for i, b := range e.Batches {
+			ops[i] = b
+		}
+		loop.PostAll(ops)
+	}
+	<-loop.Idle()
+}
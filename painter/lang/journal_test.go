@@ -0,0 +1,106 @@
+package lang_test
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// imageReceiver keeps a copy of every frame sent by the loop.
+type imageReceiver struct {
+	mu     sync.Mutex
+	frames []*image.RGBA
+}
+
+func (r *imageReceiver) Update(t screen.Texture) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, t.(*offscreen.Texture).Image())
+}
+
+func (r *imageReceiver) Frames() []*image.RGBA {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.frames
+}
+
+func TestJournal_RecordAndReplay(t *testing.T) {
+	var journal bytes.Buffer
+	recorded := &imageReceiver{}
+	loop := painter.NewLoop(recorded, 200, 200)
+	loop.Journal = lang.NewJournalWriter(&journal)
+	loop.Start(offscreen.Screen{})
+
+	loop.Post(painter.OperationList{painter.GreenBg{}, painter.BgRect{X1: 0.1, Y1: 0.1, X2: 0.9, Y2: 0.9}, painter.UpdateOp{}})
+	<-loop.Idle()
+	loop.Post(painter.OperationList{
+		painter.Figure{X: 0.2, Y: 0.3},
+		painter.Wait{Duration: 50 * time.Millisecond},
+		painter.Move{X: 0.1, Y: 0},
+		painter.UpdateOp{},
+	})
+	loop.Post(painter.AnimateMove{X: 0, Y: 0.1, Duration: 30 * time.Millisecond})
+	<-loop.Idle()
+	expectedState := loop.GetState().Snapshot()
+	loop.Stop()
+
+	entries, err := lang.ReadJournal(&journal)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 journal entries, got %d:\n%s", len(entries), journal.String())
+	}
+
+	replayed := &imageReceiver{}
+	replay := painter.NewLoop(replayed, 200, 200)
+	replay.Speed = 5
+	replay.Start(offscreen.Screen{})
+	lang.Replay(replay, entries, 5)
+	state := replay.GetState().Snapshot()
+	replay.Stop()
+
+	if !reflect.DeepEqual(state, expectedState) {
+		t.Errorf("replayed state %+v differs from recorded %+v", state, expectedState)
+	}
+	want, got := recorded.Frames(), replayed.Frames()
+	if len(want) == 0 || len(got) == 0 {
+		t.Fatalf("no frames: recorded %d, replayed %d", len(want), len(got))
+	}
+	if !bytes.Equal(want[len(want)-1].Pix, got[len(got)-1].Pix) {
+		t.Error("final replayed frame differs from the recorded one")
+	}
+}
+
+// unencodable is an operation no command can encode.
+type unencodable struct{}
+
+func (unencodable) Do(*painter.State, screen.Texture) bool { return false }
+
+func TestJournal_SkipsUnencodable(t *testing.T) {
+	var journal bytes.Buffer
+	w := lang.NewJournalWriter(&journal)
+	w.Record(time.Now(), []painter.Operation{
+		painter.OperationList{painter.WhiteBg{}, unencodable{}, painter.UpdateOp{}},
+		unencodable{},
+	})
+	if w.Skipped() != 2 {
+		t.Errorf("expected 2 skipped operations, got %d", w.Skipped())
+	}
+	entries, err := lang.ReadJournal(&journal)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	expected := []painter.OperationList{{painter.WhiteBg{}, painter.UpdateOp{}}}
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Batches, expected) {
+		t.Errorf("expected batches %+v, got %+v", expected, entries)
+	}
+}
@@ -1,124 +1,227 @@
 package lang
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/roman-mazur/architecture-lab-3/painter" // Adjust import path
 )
 
-// Parse parses a single command line into a painter.Operation.
+// Parse parses a single command line into a painter.Operation using the
+// default registry. Errors are returned as *SyntaxError.
 func Parse(commandLine string) (painter.Operation, error) {
-	fields := strings.Fields(commandLine)
-	if len(fields) == 0 {
-		return nil, errors.New("empty command")
-	}
+	return Default.Parse(commandLine)
+}
 
-	command := fields[0]
-	args := fields[1:]
-
-	switch command {
-	case "white":
-		if len(args) != 0 {
-			return nil, errors.New("white command takes no arguments")
-		}
-		return painter.WhiteBg{}, nil
-	case "green":
-		if len(args) != 0 {
-			return nil, errors.New("green command takes no arguments")
-		}
-		return painter.GreenBg{}, nil
-	case "bgrect":
-		if len(args) != 4 {
-			return nil, errors.New("bgrect command requires 4 arguments (x1 y1 x2 y2)")
-		}
-		coords := make([]float64, 4)
-		for i, arg := range args {
-			val, err := strconv.ParseFloat(arg, 64)
-			if err != nil {
-				return nil, errors.New("invalid coordinate for bgrect: " + arg)
-			}
-			if val < 0 || val > 1 {
-				return nil, errors.New("coordinate out of range (0.0-1.0) for bgrect: " + arg)
-			}
-			coords[i] = val
-		}
-		return painter.BgRect{X1: coords[0], Y1: coords[1], X2: coords[2], Y2: coords[3]}, nil
-	case "figure":
-		if len(args) != 2 {
-			return nil, errors.New("figure command requires 2 arguments (x y)")
-		}
-		coords := make([]float64, 2)
-		for i, arg := range args {
-			val, err := strconv.ParseFloat(arg, 64)
-			if err != nil {
-				return nil, errors.New("invalid coordinate for figure: " + arg)
-			}
-			if val < 0 || val > 1 {
-				return nil, errors.New("coordinate out of range (0.0-1.0) for figure: " + arg)
-			}
-			coords[i] = val
-		}
-		return painter.Figure{X: coords[0], Y: coords[1]}, nil
-	case "move":
-		if len(args) != 2 {
-			return nil, errors.New("move command requires 2 arguments (x y)")
-		}
-		coords := make([]float64, 2)
-		for i, arg := range args {
-			val, err := strconv.ParseFloat(arg, 64)
-			if err != nil {
-				return nil, errors.New("invalid offset for move: " + arg)
-			}
-			// Note: move offsets can theoretically be outside 0-1 range
-			coords[i] = val
-		}
-		return painter.Move{X: coords[0], Y: coords[1]}, nil
-	case "reset":
-		if len(args) != 0 {
-			return nil, errors.New("reset command takes no arguments")
-		}
-		return painter.Reset{}, nil
-	case "update":
-		if len(args) != 0 {
-			return nil, errors.New("update command takes no arguments")
-		}
-		return painter.UpdateOp{}, nil
-	default:
-		return nil, errors.New("unknown command: " + command)
+// ParseCommands parses a script using the default registry.
+// See Registry.ParseCommands.
+func ParseCommands(r io.Reader) ([]painter.Operation, error) {
+	return Default.ParseCommands(r)
+}
+
+// Parse parses a single command line into a painter.Operation.
+// Errors are returned as *SyntaxError.
+func (r *Registry) Parse(commandLine string) (painter.Operation, error) {
+	op, err := r.parseLine(0, commandLine)
+	if err != nil {
+		return nil, err
 	}
+	return op, nil
 }
 
-// ParseCommands parses multiple command lines from a reader.
-// (This might be better placed in http.go, but keeping similar structure)
-/*
-func ParseCommands(r io.Reader) ([]painter.Operation, error) {
-	scanner := bufio.NewScanner(r)
+// ParseCommands parses a script with one command per line. Blank lines and
+// lines starting with '#' are skipped. If any line is invalid, the returned
+// error is an ErrorList with a *SyntaxError for every invalid line.
+func (r *Registry) ParseCommands(rd io.Reader) ([]painter.Operation, error) {
+	scanner := bufio.NewScanner(rd)
 	var ops []painter.Operation
-	var errors []string
+	var errs ErrorList
 
-	for scanner.Scan() {
+	for line := 1; scanner.Scan(); line++ {
 		commandLine := scanner.Text()
-		op, err := Parse(commandLine)
-		if err != nil {
-			errors = append(errors, "line \""+commandLine+"\": "+err.Error())
-			continue // Skip invalid lines or decide on error handling
+		if trimmed := strings.TrimSpace(commandLine); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
 		}
-		if op != nil { // Parse might return nil for empty/comment lines if modified
-			ops = append(ops, op)
+		op, err := r.parseLine(line, commandLine)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		ops = append(ops, op)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading commands: %w", err)
 	}
+	if len(errs) > 0 {
+		return ops, errs
+	}
+	return ops, nil
+}
+
+// token is a whitespace-separated word of a command line with its 1-based
+// column in characters.
+// A token in double quotes may contain spaces; its text is unquoted.
+type token struct {
+	text string
+	col  int
+}
 
-	if len(errors) > 0 {
-		// Return partial results and aggregated error, or just the error
-		return ops, fmt.Errorf("errors parsing commands:\n%s", strings.Join(errors, "\n"))
+// tokenize splits a command line into tokens, remembering their positions.
+// Quoted tokens use Go string literal syntax, e.g. "say \"hi\"". If a quoted
+// token is malformed, the returned error blames it.
+func tokenize(commandLine string) ([]token, *SyntaxError) {
+	var toks []token
+	isSpace := func(b byte) bool { return b == ' ' || b == '\t' || b == '\r' || b == '\n' }
+	// Колонки рахуються в символах, а не в байтах, щоб вказувати на місце в рядку
+	column := func(i int) int { return utf8.RuneCountInString(commandLine[:i]) + 1 }
+	for i := 0; i < len(commandLine); {
+		if isSpace(commandLine[i]) {
+			i++
+			continue
+		}
+		start := i
+		if commandLine[i] != '"' {
+			for i < len(commandLine) && !isSpace(commandLine[i]) {
+				i++
+			}
+			toks = append(toks, token{text: commandLine[start:i], col: column(start)})
+			continue
+		}
+		// Шукаємо закривну лапку, пропускаючи екрановані символи
+		for i++; i < len(commandLine) && commandLine[i] != '"'; i++ {
+			if commandLine[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(commandLine) {
+			return nil, &SyntaxError{Column: column(start), Token: commandLine[start:], Msg: "unterminated string"}
+		}
+		i++
+		if i < len(commandLine) && !isSpace(commandLine[i]) {
+			return nil, &SyntaxError{Column: column(i), Token: commandLine[start:], Msg: "expected space after string"}
+		}
+		text, err := strconv.Unquote(commandLine[start:i])
+		if err != nil {
+			return nil, &SyntaxError{Column: column(start), Token: commandLine[start:i], Msg: "invalid string"}
+		}
+		toks = append(toks, token{text: text, col: column(start)})
 	}
+	return toks, nil
+}
 
-	return ops, nil
+func (r *Registry) parseLine(line int, commandLine string) (painter.Operation, *SyntaxError) {
+	toks, tokErr := tokenize(commandLine)
+	if tokErr != nil {
+		tokErr.Line = line
+		return nil, tokErr
+	}
+	if len(toks) == 0 {
+		return nil, &SyntaxError{Line: line, Column: 1, Msg: "empty command"}
+	}
+	name, args := toks[0], toks[1:]
+
+	cmd, ok := r.Lookup(name.text)
+	if !ok {
+		return nil, &SyntaxError{Line: line, Column: name.col, Token: name.text, Msg: "unknown command", Suggestion: suggest(name.text, r.Names())}
+	}
+	fail := func(tok token, msg string) *SyntaxError {
+		return &SyntaxError{Line: line, Column: tok.col, Token: tok.text, Msg: msg, Expected: cmd.Usage()}
+	}
+
+	switch {
+	case len(args) < cmd.required():
+		end := utf8.RuneCountInString(strings.TrimRight(commandLine, " \t\r\n")) + 1
+		return nil, fail(token{col: end}, "missing arguments for "+cmd.Name)
+	case len(args) > len(cmd.Args):
+		if _, ok := cmd.spec(len(args) - 1); !ok {
+			return nil, fail(args[len(cmd.Args)], "unexpected argument for "+cmd.Name)
+		}
+	}
+
+	values := make(Values, 0, max(len(cmd.Args), len(args)))
+	for i := 0; i < max(len(cmd.Args), len(args)); i++ {
+		spec, _ := cmd.spec(i)
+		text := spec.Default
+		if i < len(args) {
+			text = args[i].text
+		} else if text == "" || spec.Variadic {
+			break
+		}
+		v, msg := spec.convert(text)
+		if msg != "" {
+			tok := token{col: name.col}
+			if i < len(args) {
+				tok = args[i]
+			}
+			return nil, fail(tok, msg+" for "+cmd.Name)
+		}
+		values = append(values, v)
+	}
+
+	op, err := cmd.build(values)
+	if err != nil {
+		var argErr *ArgError
+		if errors.As(err, &argErr) && argErr.Index < len(args) {
+			return nil, fail(args[argErr.Index], err.Error())
+		}
+		return nil, fail(name, err.Error())
+	}
+	return op, nil
+}
+
+// convert parses the text of an argument according to its declaration.
+// On failure it returns a description of the problem.
+func (a Arg) convert(text string) (any, string) {
+	switch a.Kind {
+	case Number:
+		val, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, "invalid " + a.Name
+		}
+		if a.Bounded && (val < a.Min || val > a.Max) {
+			return nil, fmt.Sprintf("%s out of range (%g-%g)", a.Name, a.Min, a.Max)
+		}
+		return val, ""
+	case Duration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return nil, "invalid duration " + a.Name
+		}
+		if d < 0 {
+			return nil, "negative duration " + a.Name
+		}
+		return d, ""
+	case Word:
+		if len(a.Choices) > 0 && !slices.Contains(a.Choices, text) {
+			return nil, fmt.Sprintf("%s must be one of %s", a.Name, strings.Join(a.Choices, ", "))
+		}
+		return text, ""
+	case Integer:
+		val, err := strconv.ParseFloat(text, 64)
+		if err != nil || val != math.Trunc(val) {
+			return nil, "invalid " + a.Name + ", expected a whole number"
+		}
+		if a.Bounded && (val < a.Min || val > a.Max) {
+			return nil, fmt.Sprintf("%s out of range (%g-%g)", a.Name, a.Min, a.Max)
+		}
+		return int(val), ""
+	case Text:
+		return text, ""
+	case Color:
+		c, err := painter.ParseColor(text)
+		if err != nil {
+			return nil, fmt.Sprintf("invalid %s %q, expected #rrggbb or #rrggbbaa", a.Name, text)
+		}
+		return c, ""
+	}
+	return nil, "unsupported argument kind " + a.Kind.String()
 }
-*/
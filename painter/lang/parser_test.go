@@ -1,8 +1,12 @@
 package lang_test // Use the _test package convention
 
 import (
+	"errors"
+	"image/color"
 	"reflect" // Needed for DeepEqual comparison
+	"strings"
 	"testing"
+	"time"
 
 	// Adjust these import paths to match your actual project structure/module path
 	"github.com/roman-mazur/architecture-lab-3/painter"
@@ -59,6 +63,114 @@ func TestParse(t *testing.T) {
 			expectedOp:  painter.Move{X: 0.1, Y: -0.2},
 			expectError: false,
 		},
+		{
+			name:        "parse wait command",
+			commandLine: "wait 1.5s",
+			expectedOp:  painter.Wait{Duration: 1500 * time.Millisecond},
+			expectError: false,
+		},
+		{
+			name:        "parse save command",
+			commandLine: "save my-scene_1",
+			expectedOp:  painter.Save{Name: "my-scene_1"},
+			expectError: false,
+		},
+		{
+			name:        "parse load command",
+			commandLine: "load my-scene_1",
+			expectedOp:  painter.Load{Name: "my-scene_1"},
+			expectError: false,
+		},
+		{
+			name:        "parse export-svg command",
+			commandLine: "export-svg report",
+			expectedOp:  painter.ExportSVG{Name: "report"},
+			expectError: false,
+		},
+		{
+			name:        "parse record start command",
+			commandLine: "record start demo",
+			expectedOp:  painter.RecordStart{Name: "demo", Format: "gif"},
+			expectError: false,
+		},
+		{
+			name:        "parse record start command with format and duration",
+			commandLine: "record start demo png 2s",
+			expectedOp:  painter.RecordStart{Name: "demo", Format: "png", Duration: 2 * time.Second},
+			expectError: false,
+		},
+		{
+			name:        "parse record stop command",
+			commandLine: "record stop",
+			expectedOp:  painter.RecordStop{},
+			expectError: false,
+		},
+		{
+			name:        "parse circle command default color",
+			commandLine: "circle 0.5 0.5 0.2",
+			expectedOp:  painter.Circle{X: 0.5, Y: 0.5, R: 0.2, Color: color.NRGBA{R: 0xff, A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse ellipse command with translucent color",
+			commandLine: "ellipse 0.5 0.5 0.3 0.1 #0000ff80",
+			expectedOp:  painter.Ellipse{X: 0.5, Y: 0.5, RX: 0.3, RY: 0.1, Color: color.NRGBA{B: 0xff, A: 0x80}},
+			expectError: false,
+		},
+		{
+			name:        "parse line command",
+			commandLine: "line 0 0 1 0.5 4 #00ff00",
+			expectedOp:  painter.Line{X1: 0, Y1: 0, X2: 1, Y2: 0.5, Thickness: 4, Color: color.NRGBA{G: 0xff, A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse polygon command",
+			commandLine: "polygon #000000 0.1 0.1 0.9 0.1 0.9 0.9 0.1 0.9",
+			expectedOp:  painter.Polygon{Points: [][2]float64{{0.1, 0.1}, {0.9, 0.1}, {0.9, 0.9}, {0.1, 0.9}}, Color: color.NRGBA{A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse rotate command",
+			commandLine: "rotate 2 -45.5",
+			expectedOp:  painter.Rotate{ID: 2, Angle: -45.5},
+			expectError: false,
+		},
+		{
+			name:        "parse figure command with size",
+			commandLine: "figure 0.5 0.5 0.1",
+			expectedOp:  painter.Figure{X: 0.5, Y: 0.5, Size: 0.1},
+			expectError: false,
+		},
+		{
+			name:        "parse scale command",
+			commandLine: "scale 1 1.5",
+			expectedOp:  painter.Scale{ID: 1, Factor: 1.5},
+			expectError: false,
+		},
+		{
+			name:        "parse antialias on",
+			commandLine: "antialias on",
+			expectedOp:  painter.Antialias{Enabled: true},
+			expectError: false,
+		},
+		{
+			name:        "parse antialias off",
+			commandLine: "antialias off",
+			expectedOp:  painter.Antialias{},
+			expectError: false,
+		},
+		{
+			name:        "parse animate-move command default easing",
+			commandLine: "animate-move 0.2 -0.1 2s",
+			expectedOp:  painter.AnimateMove{X: 0.2, Y: -0.1, Duration: 2 * time.Second, Easing: "linear"},
+			expectError: false,
+		},
+		{
+			name:        "parse animate-move command with easing",
+			commandLine: "animate-move 0.2 0.2 500ms ease-in-out",
+			expectedOp:  painter.AnimateMove{X: 0.2, Y: 0.2, Duration: 500 * time.Millisecond, Easing: "ease-in-out"},
+			expectError: false,
+		},
 		{
 			name:        "parse command with extra spaces",
 			commandLine: "  figure   0.3   0.7  ",
@@ -109,6 +221,192 @@ func TestParse(t *testing.T) {
 			expectedOp:  nil,
 			expectError: true,
 		},
+		{
+			name:        "parse bg-gradient linear",
+			commandLine: "bg-gradient linear #fff #000 90",
+			expectedOp:  painter.BgFill{Kind: painter.LinearGradient, From: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, To: color.NRGBA{A: 0xff}, Angle: 90},
+			expectError: false,
+		},
+		{
+			name:        "parse bg-gradient radial",
+			commandLine: "bg-gradient radial #ff0000 #0000ff80",
+			expectedOp:  painter.BgFill{Kind: painter.RadialGradient, From: color.NRGBA{R: 0xff, A: 0xff}, To: color.NRGBA{B: 0xff, A: 0x80}},
+			expectError: false,
+		},
+		{
+			name:        "parse bg-gradient radial with angle",
+			commandLine: "bg-gradient radial #fff #000 45",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse bg-pattern stripes",
+			commandLine: "bg-pattern stripes #000 #fff 0.1 45",
+			expectedOp:  painter.BgFill{Kind: painter.Stripes, From: color.NRGBA{A: 0xff}, To: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, Size: 0.1, Angle: 45},
+			expectError: false,
+		},
+		{
+			name:        "parse bg-pattern checker with angle",
+			commandLine: "bg-pattern checker #000 #fff 0.1 45",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse bg-pattern unknown kind",
+			commandLine: "bg-pattern dots #000 #fff",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse bgrect with opacity",
+			commandLine: "bgrect 0.1 0.2 0.8 0.9 0.5",
+			expectedOp:  painter.BgRect{X1: 0.1, Y1: 0.2, X2: 0.8, Y2: 0.9, Opacity: 0.5},
+			expectError: false,
+		},
+		{
+			name:        "parse bgrect zero opacity",
+			commandLine: "bgrect 0.1 0.2 0.8 0.9 0",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse opacity command",
+			commandLine: "opacity 3 0.25",
+			expectedOp:  painter.Opacity{ID: 3, Value: 0.25},
+			expectError: false,
+		},
+		{
+			name:        "parse opacity out of range",
+			commandLine: "opacity 3 1.5",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse text with spaces and escapes",
+			commandLine: `text 0.1 0.2 "Figure \"A\": 42"   24 #ff0000`,
+			expectedOp:  painter.Text{X: 0.1, Y: 0.2, Content: `Figure "A": 42`, Size: 24, Color: color.NRGBA{R: 0xff, A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse text defaults",
+			commandLine: "text 0.5 0.5 label",
+			expectedOp:  painter.Text{X: 0.5, Y: 0.5, Content: "label", Size: 16, Color: color.NRGBA{A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse text empty string",
+			commandLine: `text 0.5 0.5 ""`,
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse text too long",
+			commandLine: "text 0.5 0.5 " + strings.Repeat("x", painter.MaxTextLength+1),
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse image natural size",
+			commandLine: "image logo 0.8 0.05",
+			expectedOp:  painter.Sprite{Name: "logo", X: 0.8, Y: 0.05},
+			expectError: false,
+		},
+		{
+			name:        "parse image scaled",
+			commandLine: "image logo 0.1 0.1 0.2 0.15",
+			expectedOp:  painter.Sprite{Name: "logo", X: 0.1, Y: 0.1, W: 0.2, H: 0.15},
+			expectError: false,
+		},
+		{
+			name:        "parse image width without height",
+			commandLine: "image logo 0.1 0.1 0.2",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse image invalid name",
+			commandLine: "image ../logo 0.1 0.1",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse layer",
+			commandLine: "layer overlay",
+			expectedOp:  painter.Layer{Name: "overlay"},
+			expectError: false,
+		},
+		{
+			name:        "parse layer-move",
+			commandLine: "layer-move overlay bottom",
+			expectedOp:  painter.MoveLayer{Name: "overlay", To: painter.LayerBottom},
+			expectError: false,
+		},
+		{
+			name:        "parse layer-move invalid position",
+			commandLine: "layer-move overlay left",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse layer-hide",
+			commandLine: "layer-hide labels",
+			expectedOp:  painter.ShowLayer{Name: "labels", Visible: false},
+			expectError: false,
+		},
+		{
+			name:        "parse layer-show",
+			commandLine: "layer-show labels",
+			expectedOp:  painter.ShowLayer{Name: "labels", Visible: true},
+			expectError: false,
+		},
+		{
+			name:        "parse layer-clear invalid name",
+			commandLine: "layer-clear ../labels",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse group",
+			commandLine: "group logo 1 3 4",
+			expectedOp:  painter.Group{Name: "logo", IDs: []int{1, 3, 4}},
+			expectError: false,
+		},
+		{
+			name:        "parse group without ids",
+			commandLine: "group logo",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse group invalid id",
+			commandLine: "group logo 1 0",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse group-move",
+			commandLine: "group-move logo 0.1 -0.2",
+			expectedOp:  painter.MoveGroup{Name: "logo", X: 0.1, Y: -0.2},
+			expectError: false,
+		},
+		{
+			name:        "parse group-color",
+			commandLine: "group-color logo #00f",
+			expectedOp:  painter.RecolorGroup{Name: "logo", Color: color.NRGBA{B: 0xff, A: 0xff}},
+			expectError: false,
+		},
+		{
+			name:        "parse group-hide",
+			commandLine: "group-hide logo",
+			expectedOp:  painter.ShowGroup{Name: "logo", Visible: false},
+			expectError: false,
+		},
+		{
+			name:        "parse group-delete invalid name",
+			commandLine: "group-delete logo/1",
+			expectedOp:  nil,
+			expectError: true,
+		},
 		{
 			name:        "parse bgrect too few args",
 			commandLine: "bgrect 0.1 0.2 0.8",
@@ -117,7 +415,7 @@ func TestParse(t *testing.T) {
 		},
 		{
 			name:        "parse bgrect too many args",
-			commandLine: "bgrect 0.1 0.2 0.8 0.9 1.0",
+			commandLine: "bgrect 0.1 0.2 0.8 0.9 1.0 0.5",
 			expectedOp:  nil,
 			expectError: true,
 		},
@@ -147,7 +445,7 @@ func TestParse(t *testing.T) {
 		},
 		{
 			name:        "parse figure too many args",
-			commandLine: "figure 0.5 0.6 0.7",
+			commandLine: "figure 0.5 0.6 0.7 0.8",
 			expectedOp:  nil,
 			expectError: true,
 		},
@@ -187,6 +485,114 @@ func TestParse(t *testing.T) {
 			expectedOp:  nil,
 			expectError: true,
 		},
+		{
+			name:        "parse save with path in name",
+			commandLine: "save ../scene",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse load without name",
+			commandLine: "load",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse record start without name",
+			commandLine: "record start",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse record stop with name",
+			commandLine: "record stop demo",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse record start too long",
+			commandLine: "record start demo gif 2h",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse record unknown format",
+			commandLine: "record start demo mp4",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse circle invalid color",
+			commandLine: "circle 0.5 0.5 0.2 red",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse polygon with two vertices",
+			commandLine: "polygon #000000 0.1 0.1 0.9 0.1",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse polygon with odd coordinates",
+			commandLine: "polygon #000000 0.1 0.1 0.9 0.1 0.9 0.9 0.1",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse rotate fractional id",
+			commandLine: "rotate 1.5 90",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse rotate zero id",
+			commandLine: "rotate 0 90",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse scale zero factor",
+			commandLine: "scale 1 0",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse antialias invalid mode",
+			commandLine: "antialias yes",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse animate-move too few args",
+			commandLine: "animate-move 0.1 0.1",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse animate-move unknown easing",
+			commandLine: "animate-move 0.1 0.1 1s bouncy",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse wait without duration",
+			commandLine: "wait",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse wait invalid duration",
+			commandLine: "wait soon",
+			expectedOp:  nil,
+			expectError: true,
+		},
+		{
+			name:        "parse wait negative duration",
+			commandLine: "wait -1s",
+			expectedOp:  nil,
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -217,10 +623,87 @@ func TestParse(t *testing.T) {
 	}
 }
 
-// Optional: Add tests for ParseCommands if you implemented that function separately
-/*
+func TestParse_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name        string
+		commandLine string
+		expected    lang.SyntaxError
+	}{
+		{
+			name:        "unknown command suggests closest",
+			commandLine: "figur 0.5 0.5",
+			expected:    lang.SyntaxError{Column: 1, Token: "figur", Msg: "unknown command", Suggestion: "figure"},
+		},
+		{
+			name:        "invalid argument points at token",
+			commandLine: "figure  0.5 abc",
+			expected:    lang.SyntaxError{Column: 13, Token: "abc", Msg: "invalid y for figure", Expected: "figure x y [size]"},
+		},
+		{
+			name:        "missing argument points past the end",
+			commandLine: "bgrect 0.1 0.2 0.8",
+			expected:    lang.SyntaxError{Column: 19, Msg: "missing arguments for bgrect", Expected: "bgrect x1 y1 x2 y2 [opacity]"},
+		},
+		{
+			name:        "extra argument points at first extra token",
+			commandLine: "white now",
+			expected:    lang.SyntaxError{Column: 7, Token: "now", Msg: "unexpected argument for white", Expected: "white"},
+		},
+		{
+			name:        "unterminated string points at opening quote",
+			commandLine: `text 0.1 0.1 "oops`,
+			expected:    lang.SyntaxError{Column: 14, Token: `"oops`, Msg: "unterminated string"},
+		},
+		{
+			name:        "string followed by text without space",
+			commandLine: `text 0.1 0.1 "a"b`,
+			expected:    lang.SyntaxError{Column: 17, Token: `"a"b`, Msg: "expected space after string"},
+		},
+		{
+			name:        "columns count characters, not bytes",
+			commandLine: `text 0.1 0.1 "Привіт" 2`,
+			expected:    lang.SyntaxError{Column: 23, Token: "2", Msg: "size out of range (4-400) for text", Expected: "text x y string [size] [color]"},
+		},
+		{
+			name:        "missing argument after non-ASCII text",
+			commandLine: `text 0.1 "Привіт"`,
+			expected:    lang.SyntaxError{Column: 18, Msg: "missing arguments for text", Expected: "text x y string [size] [color]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lang.Parse(tt.commandLine)
+			var syntaxErr *lang.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) expected *lang.SyntaxError, got %T (%v)", tt.commandLine, err, err)
+			}
+			if *syntaxErr != tt.expected {
+				t.Errorf("Parse(%q) expected error %+v, got %+v", tt.commandLine, tt.expected, *syntaxErr)
+			}
+		})
+	}
+}
+
 func TestParseCommands(t *testing.T) {
-    // Similar table-driven structure, but input would be an io.Reader (e.g., strings.NewReader)
-    // and expected output would be a slice of operations ([]painter.Operation)
+	script := `# comment line
+white
+
+figure 0.5 0.5
+figure 0.5 x
+updte`
+	ops, err := lang.ParseCommands(strings.NewReader(script))
+
+	expectedOps := []painter.Operation{painter.WhiteBg{}, painter.Figure{X: 0.5, Y: 0.5}}
+	if !reflect.DeepEqual(ops, expectedOps) {
+		t.Errorf("ParseCommands expected operations %+v, got %+v", expectedOps, ops)
+	}
+
+	var errs lang.ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("ParseCommands expected lang.ErrorList, got %T (%v)", err, err)
+	}
+	if len(errs) != 2 || errs[0].Line != 5 || errs[1].Line != 6 || errs[1].Suggestion != "update" {
+		t.Errorf("ParseCommands reported unexpected diagnostics:\n%v", err)
+	}
 }
-*/
@@ -0,0 +1,39 @@
+package lang
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// PickHandler reports the id of the topmost figure at the relative point
+// given by the x and y query parameters, e.g. GET /pick?x=0.5&y=0.5.
+// The response is {"id": N}, where 0 means there is no figure.
+func PickHandler(loop *painter.Loop) http.HandlerFunc {
+	x, y := Coord("x"), Coord("y")
+	return func(w http.ResponseWriter, r *http.Request) {
+		var coords [2]float64
+		for i, spec := range []Arg{x, y} {
+			v, msg := spec.convert(r.URL.Query().Get(spec.Name))
+			if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			coords[i] = v.(float64)
+		}
+
+		// Стан належить горутині циклу, тож пошук виконується там
+		result := make(chan int, 1)
+		loop.Post(painter.Pick{X: coords[0], Y: coords[1], Result: result})
+		select {
+		case id := <-result:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]int{"id": id})
+		case <-time.After(loopTimeout):
+			http.Error(w, "Timed out waiting for the painter loop", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	}
+}
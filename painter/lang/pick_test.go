@@ -0,0 +1,45 @@
+package lang_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestPickHandler(t *testing.T) {
+	loop := painter.NewLoop(nil, 400, 400)
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	loop.Post(painter.OperationList{painter.Figure{X: 0.2, Y: 0.2, Size: 0.1}})
+	server := httptest.NewServer(lang.PickHandler(loop))
+	defer server.Close()
+
+	tests := []struct {
+		query  string
+		status int
+		id     int
+	}{
+		{"?x=0.5&y=0.55", http.StatusOK, 1}, // Початкова фігура в центрі
+		{"?x=0.2&y=0.2", http.StatusOK, 2},
+		{"?x=0.9&y=0.9", http.StatusOK, 0},
+		{"?x=0.5", http.StatusBadRequest, 0},
+		{"?x=2&y=0.5", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct{ ID int }
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || body.ID != tt.id {
+			t.Errorf("GET %s: expected status %d and id %d, got %d and %d", tt.query, tt.status, tt.id, resp.StatusCode, body.ID)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package lang
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// ArgKind is the type of a command argument.
+type ArgKind int
+
+const (
+	Number   ArgKind = iota // Floating point number, e.g. 0.5
+	Duration                // Go duration, e.g. 1.5s or 200ms
+	Word                    // Arbitrary token, optionally limited to Arg.Choices
+	Color                   // Color as #rrggbb or #rrggbbaa, or the short #rgb or #rgba
+	Integer                 // Whole number, e.g. a figure id
+	Text                    // Arbitrary string, in double quotes if it contains spaces
+)
+
+func (k ArgKind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case Duration:
+		return "duration"
+	case Word:
+		return "word"
+	case Color:
+		return "color"
+	case Integer:
+		return "integer"
+	case Text:
+		return "text"
+	}
+	return fmt.Sprintf("ArgKind(%d)", int(k))
+}
+
+// Arg declares a single command argument.
+type Arg struct {
+	Name     string
+	Kind     ArgKind
+	Bounded  bool     // If set, a Number or Integer must lie within [Min, Max]
+	Min, Max float64  // Allowed range for bounded numbers
+	Choices  []string // Allowed values of a Word, any value if empty
+	Optional bool     // Optional arguments must follow the required ones
+	Default  string   // Value used for an omitted optional argument, none if empty
+	// Variadic marks the last argument as taking all remaining values,
+	// at least one unless it is also Optional.
+	Variadic bool
+}
+
+// Coord declares a relative coordinate argument limited to 0.0-1.0.
+func Coord(name string) Arg {
+	return Arg{Name: name, Kind: Number, Bounded: true, Min: 0, Max: 1}
+}
+
+// Num declares an unbounded number argument.
+func Num(name string) Arg {
+	return Arg{Name: name, Kind: Number}
+}
+
+// ID declares the identifier of a figure or shape.
+func ID(name string) Arg {
+	return Arg{Name: name, Kind: Integer, Bounded: true, Min: 1, Max: math.MaxInt32}
+}
+
+// Dur declares a non-negative duration argument.
+func Dur(name string) Arg {
+	return Arg{Name: name, Kind: Duration}
+}
+
+// Choice declares a word argument limited to the given values.
+func Choice(name string, choices ...string) Arg {
+	return Arg{Name: name, Kind: Word, Choices: choices}
+}
+
+// Values holds the parsed arguments of a command in declaration order.
+// Omitted optional arguments without a default are absent, so len(v)
+// may be smaller than the number of declared arguments. The values of a
+// variadic argument are the last elements.
+type Values []any
+
+// Float returns the i-th argument of kind Number.
+func (v Values) Float(i int) float64 { return v[i].(float64) }
+
+// Duration returns the i-th argument of kind Duration.
+func (v Values) Duration(i int) time.Duration { return v[i].(time.Duration) }
+
+// String returns the i-th argument of kind Word or Text.
+func (v Values) String(i int) string { return v[i].(string) }
+
+// Int returns the i-th argument of kind Integer.
+func (v Values) Int(i int) int { return v[i].(int) }
+
+// Color returns the i-th argument of kind Color.
+func (v Values) Color(i int) color.NRGBA { return v[i].(color.NRGBA) }
+
+// ArgError may be returned by Command.New to blame a specific argument.
+type ArgError struct {
+	Index int // Index of the offending argument
+	Msg   string
+}
+
+func (e *ArgError) Error() string { return e.Msg }
+
+// Command describes a command of the painter language.
+type Command struct {
+	Name string
+	Help string // One-line description shown by Registry.Help
+	Args []Arg
+	// New builds the operation from the validated arguments. It must return
+	// an operation or an error.
+	New func(args Values) (painter.Operation, error)
+	// Encode is the inverse of New: it reports whether op is built by this
+	// command and returns its arguments. Commands without Encode cannot be
+	// encoded back to text or JSON.
+	Encode func(op painter.Operation) (Values, bool)
+}
+
+// Usage returns the command synopsis, e.g. "animate-move dx dy duration [easing]".
+func (c *Command) Usage() string {
+	parts := []string{c.Name}
+	for _, a := range c.Args {
+		name := a.Name
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			name = "[" + name + "]"
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// spec returns the declaration of the i-th value, taking variadic
+// arguments into account. It returns false if there is no such argument.
+func (c *Command) spec(i int) (Arg, bool) {
+	if i < len(c.Args) {
+		return c.Args[i], true
+	}
+	if n := len(c.Args); n > 0 && c.Args[n-1].Variadic {
+		return c.Args[n-1], true
+	}
+	return Arg{}, false
+}
+
+// required returns the number of mandatory arguments.
+func (c *Command) required() int {
+	n := 0
+	for _, a := range c.Args {
+		if !a.Optional {
+			n++
+		}
+	}
+	return n
+}
+
+// build calls New, rejecting a nil operation, which would crash the loop.
+func (c *Command) build(values Values) (painter.Operation, error) {
+	op, err := c.New(values)
+	if err == nil && op == nil {
+		return nil, fmt.Errorf("internal error: command %s built no operation", c.Name)
+	}
+	return op, err
+}
+
+// Registry maps command names to their declarations.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]*Command
+	names    []string // Registration order, used for help and suggestions
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]*Command)}
+}
+
+// Default is the registry used by Parse, ParseCommands and HttpHandler.
+// It contains the built-in commands.
+var Default = NewRegistry()
+
+// Register adds a command to the default registry.
+func Register(c Command) error {
+	return Default.Register(c)
+}
+
+// Register adds a command. It fails if the declaration is invalid or the
+// name is already taken.
+func (r *Registry) Register(c Command) error {
+	if c.Name == "" || strings.ContainsAny(c.Name, " \t") {
+		return fmt.Errorf("invalid command name %q", c.Name)
+	}
+	if c.New == nil {
+		return fmt.Errorf("command %s has no constructor", c.Name)
+	}
+	optional := false
+	for i, a := range c.Args {
+		if optional && !a.Optional {
+			return fmt.Errorf("command %s: required argument %s follows an optional one", c.Name, a.Name)
+		}
+		if a.Variadic && i != len(c.Args)-1 {
+			return fmt.Errorf("command %s: variadic argument %s is not the last one", c.Name, a.Name)
+		}
+		optional = a.Optional
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.commands[c.Name]; ok {
+		return fmt.Errorf("command %s is already registered", c.Name)
+	}
+	r.commands[c.Name] = &c
+	r.names = append(r.names, c.Name)
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(c Command) {
+	if err := r.Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the command with the given name.
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.commands[name]
+	return c, ok
+}
+
+// Names returns the names of all registered commands in registration order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Help returns a description of all registered commands, one per line.
+func (r *Registry) Help() string {
+	var b strings.Builder
+	for _, name := range r.Names() {
+		c, _ := r.Lookup(name)
+		fmt.Fprintf(&b, "%-40s %s\n", c.Usage(), c.Help)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package lang_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestRegistry_CustomCommand(t *testing.T) {
+	reg := lang.NewRegistry()
+	size := lang.Arg{Name: "size", Kind: lang.Number, Bounded: true, Min: 0, Max: 2, Optional: true, Default: "1"}
+	err := reg.Register(lang.Command{
+		Name: "corner",
+		Help: "Add a figure in a corner.",
+		Args: []lang.Arg{lang.Choice("where", "tl", "br"), size},
+		New: func(a lang.Values) (painter.Operation, error) {
+			if a.String(0) == "br" && a.Float(1) > 1 {
+				return nil, &lang.ArgError{Index: 1, Msg: "size too big for br"}
+			}
+			if a.String(0) == "tl" {
+				return painter.Figure{X: 0, Y: 0}, nil
+			}
+			return painter.Figure{X: 1, Y: 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	op, err := reg.Parse("corner tl")
+	if err != nil || !reflect.DeepEqual(op, painter.Figure{X: 0, Y: 0}) {
+		t.Errorf("Parse(corner tl) = %+v, %v", op, err)
+	}
+
+	_, err = reg.Parse("corner br 1.5")
+	var syntaxErr *lang.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Column != 11 || syntaxErr.Msg != "size too big for br" {
+		t.Errorf("Parse(corner br 1.5) expected error at size argument, got %v", err)
+	}
+
+	_, err = reg.Parse("corner middle")
+	if !errors.As(err, &syntaxErr) || syntaxErr.Token != "middle" {
+		t.Errorf("Parse(corner middle) expected error at where argument, got %v", err)
+	}
+
+	if err := reg.Register(lang.Command{Name: "corner", New: func(lang.Values) (painter.Operation, error) { return nil, nil }}); err == nil {
+		t.Error("Registering a duplicate command should fail")
+	}
+	if help := reg.Help(); !strings.Contains(help, "corner where [size]") || !strings.Contains(help, "Add a figure in a corner.") {
+		t.Errorf("Help output is missing the command:\n%s", help)
+	}
+}
+
+func TestRegistry_NilOperation(t *testing.T) {
+	reg := lang.NewRegistry()
+	reg.MustRegister(lang.Command{Name: "noop", New: func(lang.Values) (painter.Operation, error) { return nil, nil }})
+
+	if op, err := reg.Parse("noop"); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("Parse(noop) = %+v, %v; expected an internal error", op, err)
+	}
+	if ops, err := reg.DecodeJSON([]byte(`[{"op":"noop"}]`)); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("DecodeJSON(noop) = %+v, %v; expected an internal error", ops, err)
+	}
+}
+
+func TestRegistry_Variadic(t *testing.T) {
+	reg := lang.NewRegistry()
+	var got lang.Values
+	sum := lang.Num("n")
+	sum.Variadic = true
+	err := reg.Register(lang.Command{
+		Name: "sum",
+		Args: []lang.Arg{lang.Choice("mode", "add"), sum},
+		New: func(a lang.Values) (painter.Operation, error) {
+			got = a
+			return painter.UpdateOp{}, nil
+		},
+		Encode: func(op painter.Operation) (lang.Values, bool) { return lang.Values{"add", 1.0, 2.0, 3.0}, true },
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, err := reg.Parse("sum add 1 2 3"); err != nil || !reflect.DeepEqual(got, lang.Values{"add", 1.0, 2.0, 3.0}) {
+		t.Errorf("Parse(sum add 1 2 3) got values %v, error %v", got, err)
+	}
+	if _, err := reg.Parse("sum add"); err == nil {
+		t.Error("Parse(sum add) should require at least one variadic value")
+	}
+	var syntaxErr *lang.SyntaxError
+	if _, err := reg.Parse("sum add 1 x"); !errors.As(err, &syntaxErr) || syntaxErr.Token != "x" {
+		t.Errorf("Parse(sum add 1 x) expected error at x, got %v", err)
+	}
+	if line, err := reg.Format(painter.UpdateOp{}); err != nil || line != "sum add 1 2 3" {
+		t.Errorf("Format = %q, %v", line, err)
+	}
+	if data, err := reg.EncodeJSON([]painter.Operation{painter.UpdateOp{}}); err != nil || string(data) != `[{"mode":"add","n":[1,2,3],"op":"sum"}]` {
+		t.Errorf("EncodeJSON = %s, %v", data, err)
+	}
+	if help := reg.Help(); !strings.Contains(help, "sum mode n...") {
+		t.Errorf("Help output is missing the variadic usage:\n%s", help)
+	}
+
+	bad := lang.Num("n")
+	bad.Variadic = true
+	if err := reg.Register(lang.Command{Name: "bad", Args: []lang.Arg{bad, lang.Num("m")}, New: func(lang.Values) (painter.Operation, error) { return nil, nil }}); err == nil {
+		t.Error("Registering a variadic argument before another one should fail")
+	}
+}
+
+func TestRegistry_DefaultHasBuiltins(t *testing.T) {
+	for _, name := range []string{"white", "green", "bgrect", "figure", "move", "reset", "wait", "animate-move", "circle", "ellipse", "line", "polygon", "update"} {
+		if _, ok := lang.Default.Lookup(name); !ok {
+			t.Errorf("Built-in command %s is not registered", name)
+		}
+	}
+}
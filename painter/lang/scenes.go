@@ -0,0 +1,90 @@
+package lang
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// loopTimeout limits how long a request waits for an operation
+// that reports its result to be run by the loop.
+const loopTimeout = 5 * time.Second
+
+// ScenesHandler serves saved scenes under the given mux:
+//
+//	GET  /scenes              list of saved scene names
+//	GET  /scenes/{name}       scene file
+//	PUT  /scenes/{name}       save the current state
+//	POST /scenes/{name}/load  validate the scene and show it
+func ScenesHandler(loop *painter.Loop) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scenes", func(w http.ResponseWriter, r *http.Request) {
+		names, err := painter.ListScenes()
+		if err != nil {
+			log.Printf("Scenes: Error listing scenes: %v", err)
+			http.Error(w, "Error listing scenes", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(names)
+	})
+	mux.HandleFunc("GET /scenes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		snap, err := painter.LoadScene(r.PathValue("name"))
+		if err != nil {
+			sceneError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(painter.Scene{Version: painter.SceneVersion, State: snap})
+	})
+	mux.HandleFunc("PUT /scenes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := painter.ValidSceneName(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The state belongs to the loop goroutine, so the save runs there.
+		result := make(chan error, 1)
+		loop.Post(painter.Save{Name: name, Result: result})
+		select {
+		case err := <-result:
+			if err != nil {
+				http.Error(w, "Error saving scene: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte("Scene saved\n"))
+		case <-time.After(loopTimeout):
+			http.Error(w, "Timed out waiting for the painter loop", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("POST /scenes/{name}/load", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		// The scene is checked here to report errors, but the loop loads it by
+		// name, so that the operation can be journaled and replayed.
+		if _, err := painter.LoadScene(name); err != nil {
+			sceneError(w, err)
+			return
+		}
+		loop.Post(painter.OperationList{painter.Load{Name: name}, painter.UpdateOp{}})
+		w.Write([]byte("Scene loaded\n"))
+	})
+	return mux
+}
+
+// sceneError reports a failure to load a scene with a suitable status.
+func sceneError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, painter.ErrSceneNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, painter.ErrInvalidScene):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Scenes: Error reading scene: %v", err)
+		http.Error(w, "Error reading scene", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,122 @@
+package lang_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestScenesHandler(t *testing.T) {
+	prev := painter.ScenesDir
+	painter.ScenesDir = t.TempDir()
+	defer func() { painter.ScenesDir = prev }()
+
+	state := painter.State{BgColor: color.NRGBA{G: 0xff, A: 0xff}, Figures: []*painter.FigureOp{}, WindowWidth: 800, WindowHeight: 800}
+	if err := painter.SaveScene("demo", state); err != nil {
+		t.Fatalf("SaveScene failed: %v", err)
+	}
+
+	loop := painter.NewLoop(nil, 800, 800)
+	server := httptest.NewServer(lang.ScenesHandler(loop))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/scenes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	json.NewDecoder(resp.Body).Decode(&names)
+	resp.Body.Close()
+	if !reflect.DeepEqual(names, []string{"demo"}) {
+		t.Errorf("expected [demo], got %v", names)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/scenes/demo", http.StatusOK},
+		{http.MethodGet, "/scenes/missing", http.StatusNotFound},
+		{http.MethodGet, "/scenes/bad.name", http.StatusBadRequest},
+		{http.MethodPut, "/scenes/bad.name", http.StatusBadRequest},
+		{http.MethodPost, "/scenes/missing/load", http.StatusNotFound},
+		{http.MethodPost, "/scenes/demo/load", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(""))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, resp.StatusCode)
+		}
+	}
+
+	posted := loop.Mq.Pull()
+	if len(posted) != 1 {
+		t.Fatalf("expected one posted batch, got %+v", posted)
+	}
+	expected := painter.OperationList{painter.Load{Name: "demo"}, painter.UpdateOp{}}
+	if !reflect.DeepEqual(posted[0], expected) {
+		t.Errorf("expected %+v posted, got %+v", expected, posted[0])
+	}
+}
+
+func TestScenesHandler_LoadIsJournaled(t *testing.T) {
+	prev := painter.ScenesDir
+	painter.ScenesDir = t.TempDir()
+	defer func() { painter.ScenesDir = prev }()
+
+	scene := painter.State{BgColor: color.NRGBA{G: 0xff, A: 0xff}, WindowWidth: 200, WindowHeight: 200}
+	painter.Figure{X: 0.25, Y: 0.75}.Do(&scene, nil)
+	if err := painter.SaveScene("demo", scene); err != nil {
+		t.Fatalf("SaveScene failed: %v", err)
+	}
+
+	var journal bytes.Buffer
+	writer := lang.NewJournalWriter(&journal)
+	loop := painter.NewLoop(&imageReceiver{}, 200, 200)
+	loop.Journal = writer
+	loop.Start(offscreen.Screen{})
+	server := httptest.NewServer(lang.ScenesHandler(loop))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/scenes/demo/load", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	<-loop.Idle()
+	expectedState := loop.GetState().Snapshot()
+	loop.Stop()
+	if writer.Skipped() != 0 {
+		t.Errorf("expected all operations to be journaled, %d skipped", writer.Skipped())
+	}
+
+	entries, err := lang.ReadJournal(&journal)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	replay := painter.NewLoop(&imageReceiver{}, 200, 200)
+	replay.Start(offscreen.Screen{})
+	lang.Replay(replay, entries, 1)
+	state := replay.GetState().Snapshot()
+	replay.Stop()
+	if !reflect.DeepEqual(state, expectedState) {
+		t.Errorf("replayed state %+v differs from recorded %+v", state, expectedState)
+	}
+	if state.Background != "#00ff00" || len(state.Figures) != 1 {
+		t.Errorf("expected the loaded scene to be replayed, got %+v", state)
+	}
+}
@@ -0,0 +1,129 @@
+package lang
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Session feeds commands from any transport into a painter loop.
+// Complete batches (an HTTP body, a WebSocket message) are submitted with
+// SubmitText or SubmitJSON; line streams (a socket, stdin) are read with Serve.
+type Session struct {
+	Loop     *painter.Loop
+	Registry *Registry // Registry used to parse commands, Default if nil
+	Prompt   string    // Printed by Serve before every line, e.g. "> " for a REPL
+}
+
+func (s *Session) registry() *Registry {
+	if s.Registry == nil {
+		return Default
+	}
+	return s.Registry
+}
+
+// SubmitText parses a script in the text format and posts it as one batch.
+// Nothing is posted if any line is invalid. Returns the number of posted operations.
+func (s *Session) SubmitText(data []byte) (int, error) {
+	ops, err := s.registry().ParseCommands(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	s.post(ops)
+	return len(ops), nil
+}
+
+// SubmitJSON parses a JSON array of operations and posts it as one batch.
+// Nothing is posted if any operation is invalid. Returns the number of posted operations.
+func (s *Session) SubmitJSON(data []byte) (int, error) {
+	ops, err := s.registry().DecodeJSON(data)
+	if err != nil {
+		return 0, err
+	}
+	s.post(ops)
+	return len(ops), nil
+}
+
+// post sends the operations to the loop as a single batch, so that
+// 'wait' commands delay only the rest of this batch.
+func (s *Session) post(ops []painter.Operation) {
+	if len(ops) > 0 {
+		s.Loop.Post(painter.OperationList(ops))
+	}
+}
+
+// Serve reads command lines from r until EOF. Commands are collected into a
+// batch that is posted after an 'update' command, a blank line or at the end
+// of input. Invalid lines are reported to w and skipped; every posted batch
+// is acknowledged with "ok <n>".
+func (s *Session) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	var batch []painter.Operation
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.post(batch)
+		fmt.Fprintf(w, "ok %d\n", len(batch))
+		batch = nil
+	}
+
+	s.prompt(w)
+	for line := 1; scanner.Scan(); line++ {
+		commandLine := scanner.Text()
+		trimmed := strings.TrimSpace(commandLine)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+		default:
+			op, err := s.registry().parseLine(line, commandLine)
+			if err != nil {
+				fmt.Fprintf(w, "error: %v\n", err)
+				break
+			}
+			batch = append(batch, op)
+			if _, ok := op.(painter.UpdateOp); ok {
+				flush()
+			}
+		}
+		s.prompt(w)
+	}
+	flush()
+	return scanner.Err()
+}
+
+func (s *Session) prompt(w io.Writer) {
+	if s.Prompt != "" {
+		fmt.Fprint(w, s.Prompt)
+	}
+}
+
+// ServeListener accepts connections from ln, e.g. a Unix domain socket, and
+// serves each of them with s.Serve until ln is closed.
+func ServeListener(ln net.Listener, s *Session) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			log.Printf("Session: Client connected on %s", ln.Addr())
+			if err := s.Serve(conn, conn); err != nil {
+				log.Printf("Session: Error reading from client: %v", err)
+			}
+			log.Printf("Session: Client disconnected from %s", ln.Addr())
+		}()
+	}
+}
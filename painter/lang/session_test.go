@@ -0,0 +1,79 @@
+package lang_test
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestSession_Serve(t *testing.T) {
+	loop := painter.NewLoop(nil, 800, 800)
+	session := &lang.Session{Loop: loop}
+	input := `white
+figure 0.5 0.5
+update
+move 0.1 0.1
+figur 0.2 0.2
+
+# comment
+green`
+	var out strings.Builder
+
+	if err := session.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	expectedOps := []painter.Operation{
+		painter.OperationList{painter.WhiteBg{}, painter.Figure{X: 0.5, Y: 0.5}, painter.UpdateOp{}},
+		painter.OperationList{painter.Move{X: 0.1, Y: 0.1}},
+		painter.OperationList{painter.GreenBg{}},
+	}
+	if posted := loop.Mq.Pull(); !reflect.DeepEqual(posted, expectedOps) {
+		t.Errorf("expected batches %+v, got %+v", expectedOps, posted)
+	}
+	expectedOut := "ok 3\nerror: line 5, column 1: unknown command \"figur\"; did you mean \"figure\"?\nok 1\nok 1\n"
+	if out.String() != expectedOut {
+		t.Errorf("expected output %q, got %q", expectedOut, out.String())
+	}
+}
+
+func TestServeListener_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "painter.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets are unavailable: %v", err)
+	}
+	loop := painter.NewLoop(nil, 800, 800)
+	done := make(chan error, 1)
+	go func() { done <- lang.ServeListener(ln, &lang.Session{Loop: loop}) }()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write([]byte("green\nupdate\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || reply != "ok 2\n" {
+		t.Errorf("expected acknowledgment, got %q (%v)", reply, err)
+	}
+	conn.Close()
+
+	ln.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeListener returned %v after the listener was closed", err)
+	}
+	expectedOps := []painter.Operation{painter.OperationList{painter.GreenBg{}, painter.UpdateOp{}}}
+	if posted := loop.Mq.Pull(); !reflect.DeepEqual(posted, expectedOps) {
+		t.Errorf("expected %+v posted, got %+v", expectedOps, posted)
+	}
+}
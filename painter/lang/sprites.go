@@ -0,0 +1,75 @@
+package lang
+
+import (
+	"encoding/json"
+	"errors"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// maxSpriteBytes limits the size of an uploaded image file.
+const maxSpriteBytes = 16 << 20
+
+// SpritesHandler serves uploaded images under the given mux:
+//
+//	GET /sprites         list of uploaded image names
+//	GET /sprites/{name}  image as PNG
+//	PUT /sprites/{name}  upload a PNG or JPEG image, replacing one with the same name
+//
+// Uploaded images are placed on the canvas with the image command.
+func SpritesHandler(loop *painter.Loop) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sprites", func(w http.ResponseWriter, r *http.Request) {
+		names, err := painter.ListSprites()
+		if err != nil {
+			log.Printf("Sprites: Error listing images: %v", err)
+			http.Error(w, "Error listing images", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(names)
+	})
+	mux.HandleFunc("GET /sprites/{name}", func(w http.ResponseWriter, r *http.Request) {
+		img, err := painter.LoadSprite(r.PathValue("name"))
+		if err != nil {
+			spriteError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, img)
+	})
+	mux.HandleFunc("PUT /sprites/{name}", func(w http.ResponseWriter, r *http.Request) {
+		img, err := painter.SaveSprite(r.PathValue("name"), http.MaxBytesReader(w, r.Body, maxSpriteBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Image file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			spriteError(w, err)
+			return
+		}
+		// Зображення з цією назвою вже можуть бути на екрані
+		loop.Post(painter.UpdateOp{})
+		b := img.Bounds()
+		log.Printf("Sprites: Uploaded image %q of size %dx%d", r.PathValue("name"), b.Dx(), b.Dy())
+		w.Write([]byte("Image uploaded\n"))
+	})
+	return mux
+}
+
+// spriteError reports a failure to store or read an image with a suitable status.
+func spriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, painter.ErrSpriteNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, painter.ErrInvalidSprite):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Sprites: Error accessing image: %v", err)
+		http.Error(w, "Error accessing image", http.StatusInternalServerError)
+	}
+}
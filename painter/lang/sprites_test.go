@@ -0,0 +1,67 @@
+package lang_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestSpritesHandler(t *testing.T) {
+	prev := painter.SpritesDir
+	painter.SpritesDir = t.TempDir()
+	defer func() { painter.SpritesDir = prev }()
+
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewNRGBA(image.Rect(0, 0, 3, 2)))
+
+	loop := painter.NewLoop(nil, 800, 800)
+	server := httptest.NewServer(lang.SpritesHandler(loop))
+	defer server.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/sprites/logo", logo.String(), http.StatusOK},
+		{http.MethodPut, "/sprites/notes", "not an image", http.StatusBadRequest},
+		{http.MethodPut, "/sprites/bad.name", logo.String(), http.StatusBadRequest},
+		{http.MethodGet, "/sprites/logo", "", http.StatusOK},
+		{http.MethodGet, "/sprites/missing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/sprites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	json.NewDecoder(resp.Body).Decode(&names)
+	resp.Body.Close()
+	if !reflect.DeepEqual(names, []string{"logo"}) {
+		t.Errorf("expected [logo], got %v", names)
+	}
+
+	// Після завантаження вікно перемальовується, бо зображення вже може бути на екрані
+	if posted := loop.Mq.Pull(); !reflect.DeepEqual(posted, []painter.Operation{painter.UpdateOp{}}) {
+		t.Errorf("expected an update after the upload, got %+v", posted)
+	}
+}
@@ -0,0 +1,33 @@
+package lang
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// SVGHandler serves the current state of the loop as an SVG image.
+func SVGHandler(loop *painter.Loop) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The state belongs to the loop goroutine, so the export runs there.
+		var buf bytes.Buffer
+		result := make(chan error, 1)
+		loop.Post(painter.ExportSVG{Writer: &buf, Result: result})
+		select {
+		case err := <-result:
+			if err != nil {
+				log.Printf("SVG Handler: Error exporting state: %v", err)
+				http.Error(w, "Error exporting SVG", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write(buf.Bytes())
+		case <-time.After(loopTimeout):
+			http.Error(w, "Timed out waiting for the painter loop", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	}
+}
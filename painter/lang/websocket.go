@@ -0,0 +1,106 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// WSMessage is a message sent by the server over the WebSocket channel.
+type WSMessage struct {
+	Type  string                     `json:"type"`            // "ack", "error" or "frame"
+	ID    int                        `json:"id,omitempty"`    // Sequence number of the acknowledged client message
+	Ops   int                        `json:"ops,omitempty"`   // Number of operations posted for the message
+	Error string                     `json:"error,omitempty"` // Parse error for the message
+	Frame uint64                     `json:"frame,omitempty"` // Frame number of a frame notification
+	Diff  map[string]json.RawMessage `json:"diff,omitempty"`  // State fields changed since the previous frame notification
+}
+
+// upgrader uses the default origin check of gorilla/websocket: browsers may
+// only connect from pages served by the painter itself, so other sites cannot
+// draw through a painter running on the user's machine. Clients that send no
+// Origin header, like painterctl, are accepted.
+var upgrader = websocket.Upgrader{}
+
+// WebSocketHandler serves a bidirectional command channel. Every text message
+// from the client is a batch of commands, either in the line-based text format
+// or as a JSON array of operations (see EncodeJSON). The server replies to the
+// n-th message with {"type":"ack","id":n} or {"type":"error","id":n} and pushes
+// {"type":"frame"} notifications like EventsHandler.
+func WebSocketHandler(loop *painter.Loop) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WebSocket: Upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		log.Printf("WebSocket: Client %s connected", r.RemoteAddr)
+		session := &Session{Loop: loop}
+
+		// gorilla/websocket допускає лише одного записувача одночасно
+		var writeMu sync.Mutex
+		send := func(m WSMessage) error {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			return conn.WriteJSON(m)
+		}
+
+		frames, cancel := loop.Subscribe()
+		defer cancel()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			var prev map[string]json.RawMessage
+			for {
+				select {
+				case <-done:
+					return
+				case f := <-frames:
+					cur, err := stateFields(f.State)
+					if err != nil {
+						log.Printf("WebSocket: Error encoding state: %v", err)
+						continue
+					}
+					if err := send(WSMessage{Type: "frame", Frame: f.Number, Diff: diffFields(prev, cur)}); err != nil {
+						return
+					}
+					prev = cur
+				}
+			}
+		}()
+
+		for id := 1; ; id++ {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				log.Printf("WebSocket: Client %s disconnected: %v", r.RemoteAddr, err)
+				return
+			}
+			if msgType != websocket.TextMessage {
+				send(WSMessage{Type: "error", ID: id, Error: "only text messages are supported"})
+				continue
+			}
+			var n int
+			if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+				n, err = session.SubmitJSON(data)
+			} else {
+				n, err = session.SubmitText(data)
+			}
+			if err != nil {
+				log.Printf("WebSocket: Rejecting message %d: %v", id, err)
+				if send(WSMessage{Type: "error", ID: id, Error: err.Error()}) != nil {
+					return
+				}
+				continue
+			}
+			if send(WSMessage{Type: "ack", ID: id, Ops: n}) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lang_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestWebSocketHandler_AcksAndErrors(t *testing.T) {
+	loop := painter.NewLoop(nil, 800, 800)
+	server := httptest.NewServer(lang.WebSocketHandler(loop))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	messages := []string{
+		"white\nfigure 0.5 0.5",
+		"figur 0.5 0.5",
+		`[{"op":"move","dx":0.1,"dy":0}]`,
+	}
+	expected := []lang.WSMessage{
+		{Type: "ack", ID: 1, Ops: 2},
+		{Type: "error", ID: 2},
+		{Type: "ack", ID: 3, Ops: 1},
+	}
+	for i, msg := range messages {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("WriteMessage failed: %v", err)
+		}
+		var reply lang.WSMessage
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatalf("ReadJSON failed: %v", err)
+		}
+		if expected[i].Type == "error" {
+			if reply.Type != "error" || reply.ID != expected[i].ID || !strings.Contains(reply.Error, `did you mean "figure"`) {
+				t.Errorf("message %d: expected parse error, got %+v", i+1, reply)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(reply, expected[i]) {
+			t.Errorf("message %d: expected %+v, got %+v", i+1, expected[i], reply)
+		}
+	}
+
+	posted := loop.Mq.Pull()
+	expectedOps := []painter.Operation{
+		painter.OperationList{painter.WhiteBg{}, painter.Figure{X: 0.5, Y: 0.5}},
+		painter.OperationList{painter.Move{X: 0.1, Y: 0}},
+	}
+	if !reflect.DeepEqual(posted, expectedOps) {
+		t.Errorf("expected %+v posted, got %+v", expectedOps, posted)
+	}
+}
+
+func TestWebSocketHandler_Origin(t *testing.T) {
+	loop := painter.NewLoop(nil, 800, 800)
+	server := httptest.NewServer(lang.WebSocketHandler(loop))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a cross-origin connection to be rejected, got %v", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {server.URL}})
+	if err != nil {
+		t.Fatalf("expected a same-origin connection to be accepted, got %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,184 @@
+package painter
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// DefaultLayer is the name of the layer that holds the elements of a state
+// without layers. Elements of the default layer have an empty Layer.
+const DefaultLayer = "base"
+
+// LayerOp represents a named layer of elements. Layers are composited
+// bottom-up on top of the background.
+type LayerOp struct {
+	Name   string
+	Hidden bool // Приховані шари не малюються, але їхні елементи зберігаються
+}
+
+// ValidLayerName checks that the name is a valid layer name.
+func ValidLayerName(name string) error {
+	if !sceneNameRe.MatchString(name) {
+		return fmt.Errorf("invalid layer name %q: use up to 64 letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// elementLayer returns the value of the Layer field of elements in the named
+// layer: the default layer is stored as an empty string.
+func elementLayer(name string) string {
+	if name == DefaultLayer {
+		return ""
+	}
+	return name
+}
+
+// layers returns the layers bottom-up. A state without layers has only the
+// default one.
+func (s *State) layers() []*LayerOp {
+	if len(s.Layers) == 0 {
+		return []*LayerOp{{Name: DefaultLayer}}
+	}
+	return s.Layers
+}
+
+// layerIndex returns the position of the layer from the bottom, or -1.
+func (s *State) layerIndex(name string) int {
+	return slices.IndexFunc(s.layers(), func(l *LayerOp) bool { return l.Name == name })
+}
+
+// updateLayers makes the default layer explicit and replaces the layer list by
+// a modified copy, so copies of the state made by Loop.GetState are not affected.
+func (s *State) updateLayers(update func(layers []*LayerOp) []*LayerOp) {
+	s.Layers = update(slices.Clone(s.layers()))
+}
+
+// LayerByName returns the layer with the given name, or nil.
+func (s *State) LayerByName(name string) *LayerOp {
+	if i := s.layerIndex(name); i >= 0 {
+		return s.layers()[i]
+	}
+	return nil
+}
+
+// Layer defines the operation for selecting the layer new elements are added
+// to. A missing layer is created on top of the others.
+type Layer struct {
+	Name string
+}
+
+func (op Layer) Do(s *State, t screen.Texture) bool {
+	if err := ValidLayerName(op.Name); err != nil {
+		log.Printf("Layer.Do: %v", err)
+		return false
+	}
+	if s.layerIndex(op.Name) < 0 {
+		s.updateLayers(func(layers []*LayerOp) []*LayerOp { return append(layers, &LayerOp{Name: op.Name}) })
+		log.Printf("Layer.Do: Created layer %q. State now has %d layers.", op.Name, len(s.Layers))
+	}
+	s.ActiveLayer = elementLayer(op.Name)
+	log.Printf("Layer.Do: New elements are added to layer %q", op.Name)
+	return false
+}
+
+// LayerPosition defines where MoveLayer puts a layer.
+type LayerPosition int
+
+const (
+	LayerTop    LayerPosition = iota // Над усіма шарами
+	LayerBottom                      // Під усіма шарами
+	LayerUp                          // На один шар вище
+	LayerDown                        // На один шар нижче
+)
+
+// layerPositionNames maps layer positions to their names used in commands.
+var layerPositionNames = map[LayerPosition]string{LayerTop: "top", LayerBottom: "bottom", LayerUp: "up", LayerDown: "down"}
+
+func (p LayerPosition) String() string {
+	if name, ok := layerPositionNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("LayerPosition(%d)", int(p))
+}
+
+// ParseLayerPosition returns the layer position with the given name, e.g. "top".
+func ParseLayerPosition(name string) (LayerPosition, error) {
+	for p, n := range layerPositionNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown layer position %q", name)
+}
+
+// MoveLayer defines the operation for changing the order of layers.
+type MoveLayer struct {
+	Name string
+	To   LayerPosition
+}
+
+func (op MoveLayer) Do(s *State, t screen.Texture) bool {
+	i := s.layerIndex(op.Name)
+	if i < 0 {
+		log.Printf("MoveLayer.Do: No layer %q", op.Name)
+		return false
+	}
+	s.updateLayers(func(layers []*LayerOp) []*LayerOp {
+		j := i
+		switch op.To {
+		case LayerTop:
+			j = len(layers) - 1
+		case LayerBottom:
+			j = 0
+		case LayerUp:
+			j = min(i+1, len(layers)-1)
+		case LayerDown:
+			j = max(i-1, 0)
+		}
+		l := layers[i]
+		return slices.Insert(slices.Delete(layers, i, i+1), j, l)
+	})
+	log.Printf("MoveLayer.Do: Layer %q moved %v to position %d", op.Name, op.To, s.layerIndex(op.Name))
+	return false // Не вимагає негайного Update
+}
+
+// ShowLayer defines the operation for showing or hiding a layer.
+type ShowLayer struct {
+	Name    string
+	Visible bool
+}
+
+func (op ShowLayer) Do(s *State, t screen.Texture) bool {
+	i := s.layerIndex(op.Name)
+	if i < 0 {
+		log.Printf("ShowLayer.Do: No layer %q", op.Name)
+		return false
+	}
+	s.updateLayers(func(layers []*LayerOp) []*LayerOp {
+		l := *layers[i]
+		l.Hidden = !op.Visible
+		layers[i] = &l
+		return layers
+	})
+	log.Printf("ShowLayer.Do: Layer %q visible: %t", op.Name, op.Visible)
+	return false // Не вимагає негайного Update
+}
+
+// ClearLayer defines the operation for removing all elements of a layer.
+// The layer itself stays in place.
+type ClearLayer struct {
+	Name string
+}
+
+func (op ClearLayer) Do(s *State, t screen.Texture) bool {
+	if s.layerIndex(op.Name) < 0 {
+		log.Printf("ClearLayer.Do: No layer %q", op.Name)
+		return false
+	}
+	s.removeElements(func(layer, group string) bool { return layer == elementLayer(op.Name) })
+	log.Printf("ClearLayer.Do: Layer %q cleared", op.Name)
+	return false // Не вимагає негайного Update
+}
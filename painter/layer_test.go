@@ -0,0 +1,86 @@
+package painter_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// layerNames returns the names of the state layers bottom-up.
+func layerNames(s painter.State) []string {
+	var names []string
+	for _, l := range s.Layers {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func TestLayers_Render(t *testing.T) {
+	red, blue := color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0xff}
+	s := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.3, Color: red}.Do(&s, nil)
+	painter.Layer{Name: "overlay"}.Do(&s, nil)
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.2, Color: blue}.Do(&s, nil)
+	assert.Equal(t, []string{painter.DefaultLayer, "overlay"}, layerNames(s))
+	assert.Equal(t, "overlay", s.Shapes[1].Layer)
+
+	at := func(s painter.State, x, y int) color.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		painter.Render(painter.ImageRenderer{Img: img}, s)
+		return img.RGBAAt(x, y)
+	}
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, at(s, 50, 50))
+
+	// Шар за замовчуванням піднімається над накладкою
+	painter.MoveLayer{Name: painter.DefaultLayer, To: painter.LayerTop}.Do(&s, nil)
+	assert.Equal(t, []string{"overlay", painter.DefaultLayer}, layerNames(s))
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, at(s, 50, 50))
+	painter.MoveLayer{Name: painter.DefaultLayer, To: painter.LayerDown}.Do(&s, nil)
+	assert.Equal(t, []string{painter.DefaultLayer, "overlay"}, layerNames(s))
+
+	// Приховану накладку можна показати знову, елементи зберігаються
+	painter.ShowLayer{Name: "overlay"}.Do(&s, nil)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, at(s, 50, 50))
+	painter.ShowLayer{Name: "overlay", Visible: true}.Do(&s, nil)
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, at(s, 50, 50))
+
+	painter.ClearLayer{Name: "overlay"}.Do(&s, nil)
+	require.Len(t, s.Shapes, 1)
+	assert.Equal(t, 1, s.Shapes[0].ID)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, at(s, 50, 50))
+
+	// Операції з неіснуючими шарами нічого не змінюють
+	painter.MoveLayer{Name: "missing", To: painter.LayerBottom}.Do(&s, nil)
+	painter.Layer{Name: "bad name"}.Do(&s, nil)
+	assert.Equal(t, []string{painter.DefaultLayer, "overlay"}, layerNames(s))
+}
+
+func TestLayers_PickAndSnapshot(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 200, WindowHeight: 200}
+	painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)
+	painter.Layer{Name: "top"}.Do(&s, nil)
+	painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)
+	painter.Layer{Name: painter.DefaultLayer}.Do(&s, nil)
+	painter.Text{X: 0.1, Y: 0.1, Content: "base"}.Do(&s, nil)
+	assert.Equal(t, "", s.ActiveLayer)
+
+	center := image.Pt(100, 100)
+	assert.Equal(t, 2, s.FigureAt(center).ID)
+	painter.MoveLayer{Name: "top", To: painter.LayerBottom}.Do(&s, nil)
+	assert.Equal(t, 1, s.FigureAt(center).ID)
+	painter.ShowLayer{Name: painter.DefaultLayer}.Do(&s, nil)
+	assert.Equal(t, 2, s.FigureAt(center).ID)
+
+	restored := painter.State{WindowWidth: 200, WindowHeight: 200}
+	painter.Restore{Scene: s.Snapshot()}.Do(&restored, nil)
+	assert.Equal(t, s.Layers, restored.Layers)
+	assert.Equal(t, "top", restored.Figures[1].Layer)
+	assert.Equal(t, "", restored.Texts[0].Layer)
+
+	painter.Reset{}.Do(&restored, nil)
+	assert.Empty(t, restored.Layers)
+}
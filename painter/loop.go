@@ -3,10 +3,13 @@
 package painter
 
 import (
+	"errors"
 	"image"
 	"image/color"
 	"log" // Додано для логування
+	"slices"
 	"sync"
+	"time"
 
 	"golang.org/x/exp/shiny/screen"
 )
@@ -61,6 +64,25 @@ func (mq *MessageQueue) Pull() []Operation {
 	return ops
 }
 
+// PushAll adds several operations at once, so that the loop pulls them together.
+func (mq *MessageQueue) PushAll(ops []Operation) {
+	mq.mu.Lock()
+	mq.ops = append(mq.ops, ops...)
+	mq.mu.Unlock()
+
+	select {
+	case mq.ch <- struct{}{}:
+	default:
+	}
+}
+
+// empty reports whether there are no operations waiting in the queue.
+func (mq *MessageQueue) empty() bool {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return len(mq.ops) == 0
+}
+
 // Wait returns a channel that signals when new operations might be available.
 // The loop will block waiting on this channel.
 func (mq *MessageQueue) Wait() <-chan struct{} {
@@ -71,10 +93,54 @@ func (mq *MessageQueue) Wait() <-chan struct{} {
 type Loop struct {
 	Receiver Receiver      // Component to send updated textures to (e.g., ui.Visualizer)
 	Mq       *MessageQueue // Message queue for receiving operations
-	state    *State        // Internal state managed by the loop
+	Journal  Journal       // Optional journal of all operations pulled from Mq
+	// Speed scales time inside the loop: waits and animations run Speed times
+	// faster. Zero means real time. Used to replay journals quickly.
+	Speed float64
+	// Antialias enables anti-aliased rendering from the first frame.
+	// It can be switched later with the Antialias operation.
+	Antialias bool
+	state     *State // Internal state managed by the loop
+
+	screen screen.Screen // Екран, на якому створюються текстура та буфер
+	buffer screen.Buffer // Буфер для малювання через RenderImage, створюється за потреби
 
 	stop    chan struct{} // Channel to signal the loop goroutine to stop
 	stopped chan struct{} // Channel to signal when the loop goroutine has finished
+
+	pending []scheduledBatch // Відкладені залишки пакетів після Wait, відсортовані за часом
+	timer   *time.Timer      // Таймер для найближчого відкладеного пакета
+
+	animations []*animation // Анімації, що виконуються зараз
+	frames     *time.Ticker // Тікер кадрів, активний лише під час анімацій
+
+	frame       uint64                  // Номер останнього кадру, надісланого Receiver
+	subsMu      sync.Mutex              // Захищає subscribers
+	subscribers map[chan Frame]struct{} // Підписники на сповіщення про кадри
+
+	idleWaiters []chan struct{} // Канали, що закриваються, коли цикл стане бездіяльним
+
+	capture  *capture // Поточний запис кадрів (nil якщо запис не ведеться)
+	captures int      // Кількість розпочатих записів, з неї беруться ідентифікатори
+}
+
+// Journal records the operations pulled by the loop, e.g. to replay them later.
+type Journal interface {
+	// Record is called from the loop goroutine with the operations pulled
+	// from the queue at once. Every element is a batch posted by a client.
+	Record(at time.Time, ops []Operation)
+}
+
+// Frame describes a texture update sent to the Receiver.
+type Frame struct {
+	Number uint64   // Monotonically increasing frame number, starting at 1
+	State  Snapshot // State at the time of the update
+}
+
+// scheduledBatch is the remainder of a batch delayed by a Wait operation.
+type scheduledBatch struct {
+	due time.Time
+	ops OperationList
 }
 
 // NewLoop creates a new Loop for managing state and processing operations.
@@ -98,6 +164,8 @@ func NewLoop(r Receiver, width, height int) *Loop {
 
 // Start initializes the loop, sets the initial state with the figure, and runs the event processing goroutine.
 func (l *Loop) Start(s screen.Screen) {
+	l.screen = s
+	l.state.Antialias = l.Antialias
 	// Створюємо початкову текстуру розміром з вікно
 	initialTexture, err := s.NewTexture(image.Pt(l.state.WindowWidth, l.state.WindowHeight))
 	if err != nil {
@@ -138,58 +206,273 @@ func (l *Loop) Start(s screen.Screen) {
 		defer func() {
 			log.Println("Loop goroutine: Releasing texture...")
 			initialTexture.Release()
+			if l.buffer != nil {
+				l.buffer.Release()
+			}
 			log.Println("Loop goroutine: Texture released.")
 		}()
 
 		// Використовуємо одну текстуру для всіх операцій малювання
 		currentTexture := initialTexture
 
+		// Таймер створюється зупиненим і взводиться лише коли є відкладені пакети
+		l.timer = time.NewTimer(time.Hour)
+		l.timer.Stop()
+		defer l.timer.Stop()
+		l.frames = time.NewTicker(FrameInterval)
+		l.frames.Stop()
+		defer l.frames.Stop()
+
+		// Початкове оновлення виконуємо одразу, не через чергу, щоб воно не потрапляло в журнал.
+		// UpdateOp.Do намалює фон ТА початкову фігуру (бо вона вже є в l.state),
+		// і потім текстура буде надіслана до візуалізатора.
+		log.Println("Loop goroutine: Performing initial update.")
+		l.process([]Operation{UpdateOp{}}, currentTexture)
+
 		for {
 			select {
 			case <-l.stop: // Отримано сигнал зупинки
 				log.Println("Loop goroutine: Stop signal received, terminating.")
+				l.flushCapture()
 				return
 			case <-l.Mq.Wait(): // Отримано сигнал про нові операції в черзі
 				ops := l.Mq.Pull() // Витягуємо ВСІ операції з черги
 				if len(ops) > 0 {
 					log.Printf("Loop goroutine: Pulled %d operations from queue.", len(ops))
-					var needsVisualUpdate bool // Прапорець, чи потрібне оновлення екрану
-					// Обробляємо кожну операцію по черзі
-					for _, op := range ops {
-						// Метод Do операції модифікує стан (l.state) та/або
-						// малює на текстурі (currentTexture).
-						// Він повертає true, якщо це UpdateOp.
-						if op.Do(l.state, currentTexture) {
-							needsVisualUpdate = true
-						}
-					}
-
-					// Якщо хоча б одна з операцій була UpdateOp (або повернула true),
-					// надсилаємо фінальну текстуру до візуалізатора.
-					if needsVisualUpdate {
-						log.Println("Loop goroutine: Sending texture update to receiver.")
-						// Перевірка на nil перед викликом Update
-						if l.Receiver != nil {
-							l.Receiver.Update(currentTexture)
-						} else {
-							log.Println("Loop goroutine: Error - Receiver is nil.")
-						}
-					}
+					l.record(ops)
+					l.process(ops, currentTexture)
+				}
+			case <-l.timer.C: // Настав час для відкладених пакетів
+				l.process(l.takeDue(time.Now()), currentTexture)
+			case now := <-l.frames.C: // Черговий кадр анімації
+				if l.advance(now) {
+					l.process([]Operation{UpdateOp{}}, currentTexture)
 				}
 			}
+			l.rearm()
+			l.checkIdle()
 		}
 	}() // Кінець горутини обробки подій
 
-	// Надсилаємо початкову операцію UpdateOp до черги.
-	// Горутина обробки подій отримає її, викличе UpdateOp.Do,
-	// яка намалює фон ТА тепер і початкову фігуру (бо вона вже є в l.state),
-	// і потім надішле текстуру до візуалізатора.
-	log.Println("Loop.Start: Posting initial UpdateOp to the queue.")
-	l.Post(UpdateOp{})
-
 	log.Println("Loop.Start: Initialization complete, event loop running.")
 }
 
+// process executes the operations against the state and sends the texture
+// to the receiver if any of them requested a screen update.
+func (l *Loop) process(ops []Operation, t screen.Texture) {
+	var needsVisualUpdate bool // Прапорець, чи потрібне оновлення екрану
+	for _, op := range ops {
+		// Метод Do операції модифікує стан (l.state) та/або
+		// малює на текстурі (t). Він повертає true, якщо це UpdateOp.
+		if l.apply(op, t) {
+			needsVisualUpdate = true
+		}
+	}
+
+	// Якщо хоча б одна з операцій була UpdateOp (або повернула true),
+	// надсилаємо фінальну текстуру до візуалізатора.
+	if needsVisualUpdate {
+		log.Println("Loop goroutine: Sending texture update to receiver.")
+		if l.Receiver != nil {
+			l.Receiver.Update(t)
+		} else {
+			log.Println("Loop goroutine: Error - Receiver is nil.")
+		}
+		l.frame++
+		snap := l.state.Snapshot()
+		l.notify(Frame{Number: l.frame, State: snap})
+		l.captureFrame(snap, time.Now())
+	}
+	if l.capture != nil && l.capture.stopping {
+		l.stopCapture()
+	}
+}
+
+// Subscribe returns a channel receiving a Frame after every texture update
+// sent to the Receiver, and a function to cancel the subscription.
+// Frames are dropped if the subscriber does not keep up.
+func (l *Loop) Subscribe() (<-chan Frame, func()) {
+	ch := make(chan Frame, 16)
+	l.subsMu.Lock()
+	if l.subscribers == nil {
+		l.subscribers = make(map[chan Frame]struct{})
+	}
+	l.subscribers[ch] = struct{}{}
+	l.subsMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.subsMu.Lock()
+			delete(l.subscribers, ch)
+			l.subsMu.Unlock()
+		})
+	}
+}
+
+// notify sends the frame to all subscribers without blocking the loop.
+func (l *Loop) notify(f Frame) {
+	l.subsMu.Lock()
+	defer l.subsMu.Unlock()
+	for ch := range l.subscribers {
+		select {
+		case ch <- f:
+		default:
+			// Повільний підписник пропускає кадр; наступний кадр містить повний стан
+		}
+	}
+}
+
+// apply executes a single operation. Batches (OperationList) are executed
+// element by element; when a Wait is met, the rest of the batch is scheduled
+// for later instead of blocking the loop, so other clients are not delayed.
+// AnimateMove operations are turned into frame-by-frame animations,
+// RecordStart/RecordStop control the capture of frames and updates are
+// rendered into a buffer when the state has content slow to draw on a texture.
+func (l *Loop) apply(op Operation, t screen.Texture) (updated bool) {
+	switch o := op.(type) {
+	case idleProbe:
+		l.idleWaiters = append(l.idleWaiters, o.done)
+		return false
+	case AnimateMove:
+		l.animate(o)
+		return false
+	case RecordStart:
+		l.startCapture(o)
+		return false
+	case RecordStop:
+		l.requestStopCapture(o)
+		return false
+	case UpdateOp:
+		if l.state.Antialias || l.state.BgFill != nil || len(l.state.Sprites) > 0 || len(l.state.Texts) > 0 {
+			return l.renderBuffered(t)
+		}
+	case Reset, Load, Restore:
+		// Після заміни стану анімації не повинні продовжувати зсувати фігури,
+		// а відкладені залишки пакетів - змінювати новий стан
+		l.cancelAnimations()
+		l.cancelPending()
+	}
+	batch, ok := op.(OperationList)
+	if !ok {
+		return op.Do(l.state, t)
+	}
+	updated, wait, rest, suspended := l.run(batch, t)
+	if suspended {
+		l.schedule(wait, rest)
+	}
+	return updated
+}
+
+// run executes the batch until a Wait is met, also in nested batches. It
+// reports whether the batch was suspended by a Wait and returns its duration
+// with the operations left to execute after it: the rest of the nested batch
+// followed by the rest of the enclosing ones.
+func (l *Loop) run(batch OperationList, t screen.Texture) (updated bool, wait time.Duration, rest OperationList, suspended bool) {
+	for i, o := range batch {
+		switch o := o.(type) {
+		case Wait:
+			return updated, o.Duration, batch[i+1:], true
+		case OperationList:
+			u, d, tail, ok := l.run(o, t)
+			updated = updated || u
+			if ok {
+				return updated, d, slices.Concat(tail, batch[i+1:]), true
+			}
+		default:
+			if l.apply(o, t) {
+				updated = true
+			}
+		}
+	}
+	return updated, 0, nil, false
+}
+
+// renderBuffered renders the state into the loop buffer with RenderImage
+// and uploads it to the texture. It is used for anti-aliased frames, for
+// gradient and pattern backgrounds, for sprites and for text, which are slow
+// to draw on a texture.
+// If no buffer can be created, the frame is drawn on the texture directly.
+func (l *Loop) renderBuffered(t screen.Texture) bool {
+	if l.buffer == nil || l.buffer.Size() != t.Size() {
+		if l.buffer != nil {
+			l.buffer.Release()
+			l.buffer = nil
+		}
+		buf, err := l.screen.NewBuffer(t.Size())
+		if err == nil && buf.RGBA() == nil {
+			buf.Release()
+			err = errors.New("buffer has no pixels")
+		}
+		if err != nil {
+			log.Printf("Loop: Cannot create buffer for rendering: %v", err)
+			return UpdateOp{}.Do(l.state, t)
+		}
+		l.buffer = buf
+	}
+	log.Println("Loop: Rendering frame into buffer")
+	RenderImage(l.buffer.RGBA(), *l.state)
+	t.Upload(image.Point{}, l.buffer, l.buffer.Bounds())
+	return true
+}
+
+// scale converts a duration of the scene to real time according to Speed.
+func (l *Loop) scale(d time.Duration) time.Duration {
+	if l.Speed <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) / l.Speed)
+}
+
+// schedule delays the execution of ops by d. Must be called from the loop goroutine.
+func (l *Loop) schedule(d time.Duration, ops OperationList) {
+	if len(ops) == 0 {
+		return
+	}
+	d = l.scale(d)
+	due := time.Now().Add(d)
+	log.Printf("Loop: Scheduling %d operations in %v", len(ops), d)
+	// Вставляємо зі збереженням порядку: пакети з однаковим часом виконуються в порядку надходження
+	i := len(l.pending)
+	for i > 0 && l.pending[i-1].due.After(due) {
+		i--
+	}
+	l.pending = append(l.pending, scheduledBatch{})
+	copy(l.pending[i+1:], l.pending[i:])
+	l.pending[i] = scheduledBatch{due: due, ops: ops}
+}
+
+// cancelPending drops the scheduled remainders of batches, which were written
+// for the replaced state. The state is shared, so this is done for the batches
+// of all clients, not only of the one replacing the state. Stops of timed
+// captures are kept, so that captures still end on time.
+func (l *Loop) cancelPending() {
+	l.pending = slices.DeleteFunc(slices.Clone(l.pending), func(b scheduledBatch) bool {
+		stop, ok := b.ops[0].(RecordStop)
+		return len(b.ops) != 1 || !ok || stop.capture == 0
+	})
+}
+
+// takeDue removes and returns the scheduled batches whose time has come.
+func (l *Loop) takeDue(now time.Time) []Operation {
+	var ops []Operation
+	n := 0
+	for n < len(l.pending) && !l.pending[n].due.After(now) {
+		ops = append(ops, l.pending[n].ops)
+		n++
+	}
+	l.pending = l.pending[n:]
+	return ops
+}
+
+// rearm sets the timer to fire at the earliest scheduled batch, if any.
+func (l *Loop) rearm() {
+	l.timer.Stop()
+	if len(l.pending) > 0 {
+		l.timer.Reset(time.Until(l.pending[0].due))
+	}
+}
+
 // Post adds an operation to the message queue for processing.
 // This is the entry point for external components (like HTTP handlers or UI callbacks)
 // to request changes to the state.
@@ -201,6 +484,59 @@ func (l *Loop) Post(op Operation) {
 	l.Mq.Push(op)
 }
 
+// PostAll adds several operations to the message queue at once, so that they
+// are processed together and produce at most one screen update.
+func (l *Loop) PostAll(ops []Operation) {
+	if l.Mq == nil {
+		log.Println("Error: Loop.PostAll called but MessageQueue (Mq) is nil.")
+		return
+	}
+	l.Mq.PushAll(ops)
+}
+
+// idleProbe is an internal operation registering a waiter for Idle.
+type idleProbe struct {
+	done chan struct{}
+}
+
+func (op idleProbe) Do(s *State, t screen.Texture) bool { return false }
+
+// Idle returns a channel that is closed once the loop has processed all
+// operations posted before the call and has no scheduled batches or
+// running animations left.
+func (l *Loop) Idle() <-chan struct{} {
+	done := make(chan struct{})
+	l.Post(idleProbe{done: done})
+	return done
+}
+
+// checkIdle releases the Idle waiters if there is nothing left to do.
+func (l *Loop) checkIdle() {
+	if len(l.idleWaiters) == 0 || len(l.pending) > 0 || len(l.animations) > 0 || !l.Mq.empty() {
+		return
+	}
+	for _, done := range l.idleWaiters {
+		close(done)
+	}
+	l.idleWaiters = nil
+}
+
+// record passes the pulled operations to the journal, leaving out internal ones.
+func (l *Loop) record(ops []Operation) {
+	if l.Journal == nil {
+		return
+	}
+	var batches []Operation
+	for _, op := range ops {
+		if _, ok := op.(idleProbe); !ok {
+			batches = append(batches, op)
+		}
+	}
+	if len(batches) > 0 {
+		l.Journal.Record(time.Now(), batches)
+	}
+}
+
 // Stop signals the event loop goroutine to terminate gracefully.
 // It waits until the goroutine confirms stoppage.
 func (l *Loop) Stop() {
@@ -215,22 +551,10 @@ func (l *Loop) Stop() {
 // StopAndWait is required by the architecture tests but not fully implemented for graceful shutdown logic here.
 // A more robust implementation might involve waiting for the message queue to empty
 // or ensuring the UI thread has also terminated.
-func (l Loop) StopAndWait() {
-	// For now, just call Stop which waits for the loop goroutine.
-	// Note: Calling Stop on a non-pointer receiver might not work as intended
-	// if the stop/stopped channels are not shared correctly.
-	// Consider making Loop methods operate on a pointer receiver (*Loop).
-	// However, since Stop() uses channels defined in the Loop struct, it should work
-	// even with a value receiver *if* the channels themselves are correctly initialized.
-	// The current implementation uses a pointer receiver for Loop methods implicitly
-	// due to how it's used in main.go (painterLoop is a pointer).
-	// If called directly on a Loop value, it might behave unexpectedly.
-	// panic("StopAndWait not fully implemented for graceful shutdown")
+func (l *Loop) StopAndWait() {
+	// Loop містить м'ютекс, тому метод має працювати з вказівником, а не з копією
 	log.Println("Warning: StopAndWait called, performing basic Stop()...")
-	// Assuming 'l' is the actual loop instance used (likely a pointer passed around)
-	// Need to ensure 'l' is the correct instance or change method receiver to *Loop
-	// l.Stop() // This might cause issues if 'l' is a copy. Let's skip for now.
-	panic("unimplemented") // Keep panic as per original template if Stop() logic isn't added here.
+	l.Stop()
 }
 
 // GetState returns a copy of the current state. Useful for testing or debugging.
@@ -248,6 +572,11 @@ func (l *Loop) GetState() State {
 	// Create a new slice and copy figure pointers to avoid modifying the original slice directly.
 	stateCopy.Figures = make([]*FigureOp, len(l.state.Figures))
 	copy(stateCopy.Figures, l.state.Figures)
+	stateCopy.Shapes = append([]*ShapeOp(nil), l.state.Shapes...)
+	stateCopy.Sprites = append([]*SpriteOp(nil), l.state.Sprites...)
+	stateCopy.Texts = append([]*TextOp(nil), l.state.Texts...)
+	stateCopy.Layers = append([]*LayerOp(nil), l.state.Layers...)
+	stateCopy.Groups = append([]*GroupOp(nil), l.state.Groups...)
 	// Copy the BgRect if it exists
 	if l.state.BgRect != nil {
 		bgRectCopy := *l.state.BgRect
@@ -280,3 +280,160 @@ func TestLoop_Stop(t *testing.T) {
 	// 	loop.Post(OperationFunc(func(s *painter.State, tx screen.Texture) bool { return false }))
 	// }, "Post after Stop should not panic")
 }
+
+func TestLoop_WaitDelaysRestOfBatch(t *testing.T) {
+	receiver := newMockReceiver()
+	loop := painter.NewLoop(receiver, 800, 800)
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	assert.True(t, receiver.WaitForUpdate(1*time.Second), "Initial update after Start was not received")
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string, update bool) painter.Operation {
+		return OperationFunc(func(s *painter.State, t screen.Texture) bool {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return update
+		})
+	}
+
+	start := time.Now()
+	loop.Post(painter.OperationList{
+		record("before", false),
+		painter.Wait{Duration: 100 * time.Millisecond},
+		record("after", true),
+	})
+	// Операція іншого клієнта не повинна чекати на Wait
+	loop.Post(record("other", true))
+
+	assert.True(t, receiver.WaitForUpdate(1*time.Second), "Update for the other client was not received")
+	assert.True(t, receiver.WaitForUpdate(1*time.Second), "Update for the delayed part of the batch was not received")
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Delayed operations ran too early")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"before", "other", "after"}, order)
+}
+
+func TestLoop_NestedWaitDelaysEnclosingBatch(t *testing.T) {
+	loop := painter.NewLoop(newMockReceiver(), 800, 800)
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) painter.Operation {
+		return OperationFunc(func(s *painter.State, t screen.Texture) bool {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return false
+		})
+	}
+
+	loop.Post(painter.OperationList{
+		painter.OperationList{record("inner"), painter.Wait{Duration: 50 * time.Millisecond}, record("inner rest")},
+		record("outer rest"),
+	})
+	loop.Post(record("other"))
+	<-loop.Idle()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Залишок зовнішнього пакета також чекає на Wait із вкладеного
+	assert.Equal(t, []string{"inner", "other", "inner rest", "outer rest"}, order)
+}
+
+func TestLoop_ResetCancelsPendingBatches(t *testing.T) {
+	loop := painter.NewLoop(newMockReceiver(), 800, 800)
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	loop.Post(painter.OperationList{painter.Wait{Duration: time.Second}, painter.Figure{X: 0.2, Y: 0.2}})
+	loop.Post(painter.Reset{})
+	select {
+	case <-loop.Idle():
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("the loop must be idle without the cancelled batch")
+	}
+	assert.Empty(t, loop.GetState().Figures)
+}
+
+func TestLoop_ResetCancelsPendingBatchesOfAllClients(t *testing.T) {
+	loop := painter.NewLoop(newMockReceiver(), 800, 800)
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	// Два клієнти чекають, третій скидає стан: залишки обох пакетів відкидаються
+	loop.Post(painter.OperationList{painter.Wait{Duration: 100 * time.Millisecond}, painter.Figure{X: 0.2, Y: 0.2}})
+	loop.Post(painter.OperationList{painter.Wait{Duration: 100 * time.Millisecond}, painter.Figure{X: 0.8, Y: 0.8}})
+	loop.Post(painter.OperationList{painter.Reset{}, painter.UpdateOp{}})
+	// Пакети, надіслані після скидання, виконуються як зазвичай
+	loop.Post(painter.OperationList{painter.Wait{Duration: 50 * time.Millisecond}, painter.Figure{X: 0.5, Y: 0.5}})
+	<-loop.Idle() // Idle чекає і на відкладені пакети
+
+	figures := loop.GetState().Figures
+	if assert.Len(t, figures, 1) {
+		assert.Equal(t, 400, figures[0].X)
+	}
+}
+
+func TestLoop_AnimateMoveUpdatesEveryFrame(t *testing.T) {
+	receiver := newMockReceiver()
+	loop := painter.NewLoop(receiver, 800, 800)
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	assert.True(t, receiver.WaitForUpdate(1*time.Second), "Initial update after Start was not received")
+	initialCalls := receiver.UpdateCalls()
+
+	offsets := make(chan image.Point, 1)
+	loop.Post(painter.OperationList{
+		painter.Move{X: 0.1, Y: 0},
+		painter.AnimateMove{X: 0.25, Y: -0.125, Duration: 100 * time.Millisecond, Easing: "ease-in-out"},
+		painter.Wait{Duration: 300 * time.Millisecond},
+		OperationFunc(func(s *painter.State, t screen.Texture) bool {
+			offsets <- s.MoveOffset
+			return false
+		}),
+	})
+
+	select {
+	case offset := <-offsets:
+		// Анімація додається до попереднього зміщення від 'move'
+		assert.Equal(t, image.Pt(80+200, -100), offset)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Animation did not finish in time")
+	}
+	assert.Greater(t, receiver.UpdateCalls()-initialCalls, 1, "Animation should update the receiver on several frames")
+}
+
+func TestLoop_SubscribeReceivesFrames(t *testing.T) {
+	receiver := newMockReceiver()
+	loop := painter.NewLoop(receiver, 800, 800)
+	frames, cancel := loop.Subscribe()
+	defer cancel()
+	loop.Start(&mockScreen{})
+	defer loop.Stop()
+
+	assert.True(t, receiver.WaitForUpdate(1*time.Second), "Initial update after Start was not received")
+	loop.Post(painter.OperationList{painter.GreenBg{}, painter.Move{X: 0.1, Y: 0.1}, painter.UpdateOp{}})
+
+	var got []painter.Frame
+	for len(got) < 2 {
+		select {
+		case f := <-frames:
+			got = append(got, f)
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Expected 2 frames, received %d", len(got))
+		}
+	}
+	assert.Equal(t, uint64(1), got[0].Number, "Initial update should be frame 1")
+	assert.Equal(t, uint64(2), got[1].Number)
+	assert.Equal(t, "#00ff00", got[1].State.Background)
+	assert.Equal(t, painter.SnapshotPoint{X: 80, Y: 80}, got[1].State.Offset)
+	assert.Len(t, got[1].State.Figures, 1)
+}
@@ -0,0 +1,81 @@
+// Package offscreen implements screen.Screen on top of in-memory images,
+// so that painter.Loop can run without a window (replays, tests, exports).
+package offscreen
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Screen creates buffers and textures backed by *image.RGBA.
+// Windows are not supported.
+type Screen struct{}
+
+// NewBuffer returns a new Buffer of the given size.
+func (Screen) NewBuffer(size image.Point) (screen.Buffer, error) {
+	return &Buffer{rgba: image.NewRGBA(image.Rectangle{Max: size})}, nil
+}
+
+// NewTexture returns a new *Texture of the given size.
+func (Screen) NewTexture(size image.Point) (screen.Texture, error) {
+	return NewTexture(size), nil
+}
+
+// NewWindow always fails: there is no display.
+func (Screen) NewWindow(opts *screen.NewWindowOptions) (screen.Window, error) {
+	return nil, errors.New("offscreen: windows are not supported")
+}
+
+// Buffer is an in-memory screen.Buffer.
+type Buffer struct {
+	rgba *image.RGBA
+}
+
+func (b *Buffer) Release()                {}
+func (b *Buffer) Size() image.Point       { return b.rgba.Rect.Size() }
+func (b *Buffer) Bounds() image.Rectangle { return b.rgba.Rect }
+func (b *Buffer) RGBA() *image.RGBA       { return b.rgba }
+
+// Texture is an in-memory screen.Texture whose pixels can be read back.
+type Texture struct {
+	mu   sync.Mutex
+	rgba *image.RGBA
+}
+
+// NewTexture creates a transparent texture of the given size.
+func NewTexture(size image.Point) *Texture {
+	return &Texture{rgba: image.NewRGBA(image.Rectangle{Max: size})}
+}
+
+func (t *Texture) Release()                {}
+func (t *Texture) Size() image.Point       { return t.rgba.Rect.Size() }
+func (t *Texture) Bounds() image.Rectangle { return t.rgba.Rect }
+
+// Upload copies the sr part of src to the texture at dp.
+func (t *Texture) Upload(dp image.Point, src screen.Buffer, sr image.Rectangle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dr := image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}
+	draw.Draw(t.rgba, dr, src.RGBA(), sr.Min, draw.Src)
+}
+
+// Fill fills dr with the color using the given Porter-Duff operator.
+func (t *Texture) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	draw.Draw(t.rgba, dr, image.NewUniform(src), image.Point{}, op)
+}
+
+// Image returns a copy of the current texture content.
+func (t *Texture) Image() *image.RGBA {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	img := image.NewRGBA(t.rgba.Rect)
+	copy(img.Pix, t.rgba.Pix)
+	return img
+}
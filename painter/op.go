@@ -1,9 +1,12 @@
 package painter
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"log"
+	"math"
+	"time"
 
 	"golang.org/x/exp/shiny/screen"
 )
@@ -17,23 +20,151 @@ type Operation interface {
 // State holds the current drawing state managed by the loop.
 type State struct {
 	BgColor      color.Color // Поточний колір фону
+	BgFill       *BgFillOp   // Градієнт чи візерунок замість суцільного фону (nil якщо немає)
 	BgRect       *BgRectOp   // Дані для останнього фонового прямокутника (nil якщо немає)
 	Figures      []*FigureOp // Слайс усіх фігур на екрані
+	Shapes       []*ShapeOp  // Примітиви (кола, еліпси, відрізки, багатокутники), малюються після фігур
+	Sprites      []*SpriteOp // Завантажені зображення, малюються після примітивів
+	Texts        []*TextOp   // Текстові мітки, малюються поверх інших елементів свого шару
+	Layers       []*LayerOp  // Шари знизу вгору; порожній список означає лише DefaultLayer
+	ActiveLayer  string      // Шар, до якого додаються нові елементи ("" для DefaultLayer)
+	Groups       []*GroupOp  // Іменовані групи елементів із власними зміщеннями
+	LastID       int         // Останній виданий ідентифікатор фігури чи примітиву
 	MoveOffset   image.Point // Кумулятивне зміщення для команди 'move' (застосовується в UpdateOp)
 	WindowWidth  int         // Ширина вікна в пікселях
 	WindowHeight int         // Висота вікна в пікселях
+	Antialias    bool        // Малювати зі згладжуванням країв (див. AntialiasRenderer)
+}
+
+// newID returns the next identifier for a figure or shape.
+func (s *State) newID() int {
+	s.LastID++
+	return s.LastID
+}
+
+// FigureByID returns the figure with the given identifier, or nil.
+func (s *State) FigureByID(id int) *FigureOp {
+	if i := s.figureIndex(id); i >= 0 {
+		return s.Figures[i]
+	}
+	return nil
+}
+
+func (s *State) figureIndex(id int) int {
+	for i, f := range s.Figures {
+		if f.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateFigure replaces the figure with the given identifier by a modified
+// copy, so copies of the state made by Loop.GetState are not affected.
+// It reports whether the figure exists.
+func (s *State) updateFigure(id int, update func(f *FigureOp)) bool {
+	i := s.figureIndex(id)
+	if i < 0 {
+		return false
+	}
+	f := *s.Figures[i]
+	update(&f)
+	s.Figures[i] = &f
+	return true
 }
 
 // FigureOp represents the state for drawing a single figure instance.
 type FigureOp struct {
+	ID      int           // Ідентифікатор для команд на кшталт 'rotate', починаючи з 1
 	X, Y    int           // Абсолютні піксельні координати центру фігури
 	Variant FigureVariant // Тип фігури (T0, T90, T180, T270, Cross)
+	Angle   float64       // Додатковий поворот за годинниковою стрілкою в градусах, [0, 360)
+	Size    float64       // Розмір відносно меншої сторони вікна, DefaultFigureSize якщо 0
 	Color   color.Color   // Колір фігури
+	Layer   string        // Назва шару, "" для DefaultLayer
+	Group   string        // Назва групи, "" якщо фігура не в групі
+}
+
+// DefaultFigureSize is the size of figures created without one,
+// relative to the smaller side of the window.
+const DefaultFigureSize = 0.3
+
+// Bounds of the figure size relative to the smaller window side, as accepted
+// by the figure command.
+const (
+	MinFigureSize = 0.01
+	MaxFigureSize = 1.0
+)
+
+// minFigurePixels is the smallest figure size in pixels, so that small
+// figures stay recognizable.
+const minFigurePixels = 20
+
+// pixelSize returns the size of the figure in pixels for the given window.
+func (f *FigureOp) pixelSize(winWidth, winHeight int) int {
+	size := f.Size
+	if size == 0 {
+		size = DefaultFigureSize
+	}
+	return max(int(float64(min(winWidth, winHeight))*size), minFigurePixels)
+}
+
+// rects returns the rectangles of the figure before rotation, shifted by offset.
+func (f *FigureOp) rects(offset image.Point, winWidth, winHeight int) []image.Rectangle {
+	return figureRects(f.X+offset.X, f.Y+offset.Y, f.Variant, f.pixelSize(winWidth, winHeight))
+}
+
+// FigureAt returns the topmost figure covering the pixel p of the window,
+// taking the move offsets, size and rotation of figures into account, or nil.
+// Figures of hidden layers and groups are skipped.
+func (s *State) FigureAt(p image.Point) *FigureOp {
+	layers := s.layers()
+	for li := len(layers) - 1; li >= 0; li-- {
+		if layers[li].Hidden {
+			continue
+		}
+		if f := s.figureAt(p, elementLayer(layers[li].Name)); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// figureAt returns the topmost figure of the layer covering the pixel p, or nil.
+func (s *State) figureAt(p image.Point, layer string) *FigureOp {
+	for i := len(s.Figures) - 1; i >= 0; i-- {
+		f := s.Figures[i]
+		if f.Layer != layer || !s.visible(f.Group) {
+			continue
+		}
+		offset := s.offset(f.Group)
+		c := image.Pt(f.X, f.Y).Add(offset)
+		q := p
+		if f.Angle != 0 {
+			// Повертаємо точку назад замість того, щоб повертати фігуру
+			q = rotatePoint(p, c, -f.Angle)
+		}
+		for _, r := range f.rects(offset, s.WindowWidth, s.WindowHeight) {
+			if q.In(r) {
+				return f
+			}
+		}
+	}
+	return nil
 }
 
 // BgRectOp represents the state for the background rectangle.
 type BgRectOp struct {
-	X1, Y1, X2, Y2 int // Абсолютні піксельні координати прямокутника
+	X1, Y1, X2, Y2 int     // Абсолютні піксельні координати прямокутника
+	Opacity        float64 // Непрозорість у (0, 1]; 0 означає повністю непрозорий
+}
+
+// opacity returns the opacity of the rectangle, treating zero as opaque.
+func (r *BgRectOp) opacity() float64 {
+	if r.Opacity <= 0 {
+		return 1
+	}
+	return r.Opacity
 }
 
 // OperationList groups multiple operations. Useful for batch processing.
@@ -51,74 +182,56 @@ func (ol OperationList) Do(s *State, t screen.Texture) (updated bool) {
 }
 
 // UpdateOp signals that the texture should be redrawn based on the current state
-// and sent to the screen. This is where all actual drawing happens.
+// and sent to the screen. The scene itself is drawn by Render.
 type UpdateOp struct{}
 
 func (op UpdateOp) Do(s *State, t screen.Texture) bool {
 	log.Println("UpdateOp.Do: Starting update...")
-	// 1. Очищуємо текстуру поточним кольором фону зі стану (має бути білий після команди 'white')
-	log.Printf("UpdateOp.Do: Filling background with: %+v", s.BgColor)
-	t.Fill(t.Bounds(), s.BgColor, screen.Src)
-
-	// 2. Малюємо фоновий прямокутник (чорний із зеленою рамкою згідно з завданням)
-	if s.BgRect != nil {
-		// Створюємо image.Rectangle з піксельних координат
-		rect := image.Rect(s.BgRect.X1, s.BgRect.Y1, s.BgRect.X2, s.BgRect.Y2)
-		log.Printf("UpdateOp.Do: Drawing BgRect %+v", rect)
-
-		// Малюємо ЧОРНИЙ фон прямокутника
-		blackColor := color.Black
-		t.Fill(rect, blackColor, screen.Src)
-
-		// Малюємо ЗЕЛЕНУ рамку (товщиною 1 піксель)
-		greenColor := color.NRGBA{G: 0xff, A: 0xff} // Зелений
-		// Важливо: Перевіряємо, чи рамка не виходить за межі прямокутника, якщо він дуже малий
-		if rect.Dx() > 0 && rect.Dy() > 0 { // Перевірка, що прямокутник має ширину і висоту > 0
-			// Верхня лінія
-			t.Fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1).Intersect(rect), greenColor, screen.Src)
-			// Нижня лінія (якщо висота > 1)
-			if rect.Dy() > 1 {
-				t.Fill(image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y).Intersect(rect), greenColor, screen.Src)
-			}
-			// Ліва лінія (враховуючи вже намальовані кути)
-			if rect.Dx() > 1 {
-				t.Fill(image.Rect(rect.Min.X, rect.Min.Y+1, rect.Min.X+1, rect.Max.Y-1).Intersect(rect), greenColor, screen.Src)
-				// Права лінія (враховуючи вже намальовані кути)
-				t.Fill(image.Rect(rect.Max.X-1, rect.Min.Y+1, rect.Max.X, rect.Max.Y-1).Intersect(rect), greenColor, screen.Src)
-			}
-		} else if rect.Dx() > 0 { // Якщо це горизонтальна лінія
-			t.Fill(rect, greenColor, screen.Src)
-		} else if rect.Dy() > 0 { // Якщо це вертикальна лінія
-			t.Fill(rect, greenColor, screen.Src)
-		}
-
-		log.Println("UpdateOp.Do: BgRect drawn with black fill and green border.")
-	} else {
-		log.Println("UpdateOp.Do: No BgRect defined in state.")
-	}
-
-	// 3. Малюємо всі фігури зі стану (мають бути жовті T180)
-	log.Printf("UpdateOp.Do: Drawing %d figures from state.", len(s.Figures))
-	for i, fig := range s.Figures {
-		// Застосовуємо кумулятивне зміщення від команди 'move' (якщо є)
-		centerX := fig.X + s.MoveOffset.X
-		centerY := fig.Y + s.MoveOffset.Y
-		log.Printf("UpdateOp.Do: Drawing figure %d: Type=%v, Color=%+v at final coords (%d, %d)", i, fig.Variant, fig.Color, centerX, centerY)
-		// Викликаємо допоміжну функцію для малювання конкретної фігури
-		drawFigure(t, centerX, centerY, fig.Variant, fig.Color, s.WindowWidth, s.WindowHeight)
-	}
+	log.Printf("UpdateOp.Do: Drawing background %+v, BgRect %+v and %d figures with offset %v",
+		s.BgColor, s.BgRect, len(s.Figures), s.MoveOffset)
+	// Уся логіка малювання сцени знаходиться в Render, текстура - лише одна з цілей
+	Render(TextureRenderer{T: t}, *s)
 
 	// 4. Сигналізуємо, що екран потрібно оновити (текстура готова)
 	log.Println("UpdateOp.Do: Update complete, returning true.")
 	return true
 }
 
+// Colors of the background rectangle drawn by UpdateOp.
+var (
+	bgRectFillColor   color.Color = color.Black
+	bgRectBorderColor color.Color = color.NRGBA{G: 0xff, A: 0xff} // Зелений
+)
+
+// bgRectBorder returns the non-overlapping rectangles forming
+// the 1 pixel border of rect.
+func bgRectBorder(rect image.Rectangle) []image.Rectangle {
+	var border []image.Rectangle
+	// Важливо: Перевіряємо, чи рамка не виходить за межі прямокутника, якщо він дуже малий
+	if rect.Dx() > 0 && rect.Dy() > 0 { // Перевірка, що прямокутник має ширину і висоту > 0
+		// Верхня лінія
+		border = append(border, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1).Intersect(rect))
+		// Нижня лінія (якщо висота > 1)
+		if rect.Dy() > 1 {
+			border = append(border, image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y).Intersect(rect))
+		}
+		// Ліва лінія (враховуючи вже намальовані кути)
+		if rect.Dx() > 1 && rect.Dy() > 2 {
+			border = append(border, image.Rect(rect.Min.X, rect.Min.Y+1, rect.Min.X+1, rect.Max.Y-1).Intersect(rect))
+			// Права лінія (враховуючи вже намальовані кути)
+			border = append(border, image.Rect(rect.Max.X-1, rect.Min.Y+1, rect.Max.X, rect.Max.Y-1).Intersect(rect))
+		}
+	}
+	return border
+}
+
 // WhiteBg defines the operation for setting the background to white.
 type WhiteBg struct{}
 
 func (op WhiteBg) Do(s *State, t screen.Texture) bool {
 	log.Println("WhiteBg.Do: Setting background color to White")
 	s.BgColor = color.White // Змінюємо колір фону в стані
+	s.BgFill = nil          // Суцільний колір замінює градієнт чи візерунок
 	// Колір фігур не змінюємо, вони визначаються в Figure.Do
 	return false // Сама зміна кольору не вимагає негайного Update
 }
@@ -129,13 +242,16 @@ type GreenBg struct{}
 func (op GreenBg) Do(s *State, t screen.Texture) bool {
 	log.Println("GreenBg.Do: Setting background color to Green")
 	s.BgColor = color.NRGBA{G: 0xff, A: 0xff} // Змінюємо колір фону в стані
+	s.BgFill = nil                            // Суцільний колір замінює градієнт чи візерунок
 	// Колір фігур не змінюємо, вони визначаються в Figure.Do
 	return false // Не вимагає негайного Update
 }
 
 // Figure defines the operation for ADDING a new figure.
+// Size is relative to the smaller side of the window, DefaultFigureSize if 0.
 type Figure struct {
 	X, Y float64
+	Size float64
 }
 
 // Do для Figure: ЗАВЖДИ додає нову фігуру (ЖОВТА T180).
@@ -151,13 +267,19 @@ func (op Figure) Do(s *State, t screen.Texture) bool {
 	figureVariant := T180                                 // Тип T180
 
 	newFig := &FigureOp{
+		ID:      s.newID(),
 		X:       pixelX,
 		Y:       pixelY,
 		Variant: figureVariant,
+		Size:    op.Size,
 		Color:   figureColor, // Використовуємо жовтий колір
+		Layer:   s.ActiveLayer,
+	}
+	if newFig.Size == 0 {
+		newFig.Size = DefaultFigureSize
 	}
 	s.Figures = append(s.Figures, newFig) // Додаємо вказівник на нову фігуру до слайсу
-	log.Printf("Figure.Do: Successfully added YELLOW T180 figure. State now has %d figures.", len(s.Figures))
+	log.Printf("Figure.Do: Successfully added YELLOW T180 figure %d. State now has %d figures.", newFig.ID, len(s.Figures))
 	// -----------------------------------
 
 	// Сама операція Figure не вимагає негайного оновлення екрану.
@@ -169,6 +291,7 @@ func (op Figure) Do(s *State, t screen.Texture) bool {
 // Coordinates are relative (0.0 to 1.0).
 type BgRect struct {
 	X1, Y1, X2, Y2 float64
+	Opacity        float64 // Непрозорість у (0, 1]; 0 означає повністю непрозорий
 }
 
 func (op BgRect) Do(s *State, t screen.Texture) bool {
@@ -187,7 +310,7 @@ func (op BgRect) Do(s *State, t screen.Texture) bool {
 	if py1 > py2 {
 		py1, py2 = py2, py1
 	}
-	s.BgRect = &BgRectOp{X1: px1, Y1: py1, X2: px2, Y2: py2}
+	s.BgRect = &BgRectOp{X1: px1, Y1: py1, X2: px2, Y2: py2, Opacity: op.Opacity}
 	return false // Не вимагає негайного Update
 }
 
@@ -205,19 +328,135 @@ func (op Move) Do(s *State, t screen.Texture) bool {
 	return false // Не вимагає негайного Update
 }
 
+// Rotate defines the operation for setting the rotation angle of a figure.
+// The angle is absolute and clockwise, applied on top of the orientation of
+// the figure variant, so T0..T270 remain available as presets.
+type Rotate struct {
+	ID    int
+	Angle float64 // В градусах
+}
+
+func (op Rotate) Do(s *State, t screen.Texture) bool {
+	angle := math.Mod(op.Angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	if !s.updateFigure(op.ID, func(f *FigureOp) { f.Angle = angle }) {
+		log.Printf("Rotate.Do: No figure with id %d", op.ID)
+		return false
+	}
+	log.Printf("Rotate.Do: Figure %d rotated to %.1f°", op.ID, angle)
+	return false // Не вимагає негайного Update
+}
+
+// Scale defines the operation for multiplying the size of a figure by Factor.
+type Scale struct {
+	ID     int
+	Factor float64
+}
+
+func (op Scale) Do(s *State, t screen.Texture) bool {
+	if op.Factor <= 0 {
+		log.Printf("Scale.Do: Ignoring non-positive factor %g", op.Factor)
+		return false
+	}
+	var size float64
+	ok := s.updateFigure(op.ID, func(f *FigureOp) {
+		if f.Size == 0 {
+			f.Size = DefaultFigureSize
+		}
+		// Розмір залишається в межах, які приймає команда figure
+		f.Size = math.Min(math.Max(f.Size*op.Factor, MinFigureSize), MaxFigureSize)
+		size = f.Size
+	})
+	if !ok {
+		log.Printf("Scale.Do: No figure with id %d", op.ID)
+		return false
+	}
+	log.Printf("Scale.Do: Figure %d scaled by %g to size %.3f", op.ID, op.Factor, size)
+	return false // Не вимагає негайного Update
+}
+
+// Opacity defines the operation for setting the opacity of a figure, a shape
+// or a text label, from 0 (invisible) to 1 (opaque). Overlapping translucent
+// elements blend with what is drawn under them.
+type Opacity struct {
+	ID    int
+	Value float64
+}
+
+func (op Opacity) Do(s *State, t screen.Texture) bool {
+	if op.Value < 0 || op.Value > 1 {
+		log.Printf("Opacity.Do: Ignoring opacity %g out of range [0, 1]", op.Value)
+		return false
+	}
+	// Непрозорість зберігається в альфа-каналі кольору елемента
+	ok := s.updateFigure(op.ID, func(f *FigureOp) { f.Color = withOpacity(f.Color, op.Value) }) ||
+		s.updateShape(op.ID, func(sh *ShapeOp) { sh.Color = withOpacity(sh.Color, op.Value) }) ||
+		s.updateText(op.ID, func(tx *TextOp) { tx.Color = withOpacity(tx.Color, op.Value) })
+	if !ok {
+		log.Printf("Opacity.Do: No figure, shape or text with id %d", op.ID)
+		return false
+	}
+	log.Printf("Opacity.Do: Element %d opacity set to %g", op.ID, op.Value)
+	return false // Не вимагає негайного Update
+}
+
+// Pick defines the operation for finding the topmost figure at a relative
+// point. The id of the figure, or 0 if there is none, is sent to Result.
+type Pick struct {
+	X, Y   float64
+	Result chan<- int // Buffered channel receiving the id
+}
+
+func (op Pick) Do(s *State, t screen.Texture) bool {
+	id := 0
+	if f := s.FigureAt(relPoint(s, op.X, op.Y)); f != nil {
+		id = f.ID
+	}
+	log.Printf("Pick.Do: Figure at (%.2f, %.2f): %d", op.X, op.Y, id)
+	if op.Result != nil {
+		op.Result <- id
+	}
+	return false
+}
+
 // Reset defines the operation for clearing the state to default values.
 type Reset struct{}
 
 func (op Reset) Do(s *State, t screen.Texture) bool {
 	log.Println("Reset.Do: Resetting state...")
 	s.BgColor = color.Black      // Скидаємо фон на чорний
+	s.BgFill = nil               // Видаляємо градієнт чи візерунок
 	s.BgRect = nil               // Видаляємо фоновий прямокутник
 	s.Figures = []*FigureOp{}    // Очищуємо список фігур
+	s.Shapes = nil               // Очищуємо примітиви
+	s.Sprites = nil              // Прибираємо зображення
+	s.Texts = nil                // Очищуємо текстові мітки
+	s.Layers = nil               // Залишається лише шар за замовчуванням
+	s.ActiveLayer = ""           // Нові елементи знову додаються до нього
+	s.Groups = nil               // Групи видаляються разом з елементами
+	s.LastID = 0                 // Нумерація починається заново
 	s.MoveOffset = image.Point{} // Скидаємо зміщення
 	log.Println("Reset.Do: State reset complete. Requesting screen update.")
 	return true // Повертаємо true, щоб екран очистився
 }
 
+// Wait delays the operations that follow it in the same batch (OperationList).
+// The loop schedules the rest of the batch instead of sleeping, so operations
+// posted by other clients keep being processed in the meantime.
+// Reset, Load and Restore drop every batch still waiting, whichever client
+// posted it, because the rest of the batch was written for the replaced state.
+type Wait struct {
+	Duration time.Duration
+}
+
+func (op Wait) Do(s *State, t screen.Texture) bool {
+	// Затримку виконує Loop; поза пакетом Wait нічого не робить
+	log.Printf("Wait.Do: Wait %v outside of a batch has no effect", op.Duration)
+	return false
+}
+
 // FigureVariant defines the type of figure to draw.
 type FigureVariant int
 
@@ -229,16 +468,29 @@ const (
 	Cross                      // Хрест
 )
 
-// drawFigure - допоміжна функція для малювання фігури на текстурі.
-// cx, cy - піксельні координати центру фігури.
-func drawFigure(t screen.Texture, cx, cy int, variant FigureVariant, figureColor color.Color, winWidth, winHeight int) {
-	// Визначаємо базові розміри фігури (можна зробити їх динамічними або константами)
-	// За умовою, не більше половини вікна. Візьмемо фіксований розмір, наприклад 30% меншої сторони вікна.
-	baseSize := int(float64(min(winWidth, winHeight)) * 0.3) // Розмір фігури відносно вікна
-	if baseSize < 20 {
-		baseSize = 20
-	} // Мінімальний розмір
+// figureVariantNames maps variants to their names used in snapshots.
+var figureVariantNames = map[FigureVariant]string{T0: "T0", T90: "T90", T180: "T180", T270: "T270", Cross: "Cross"}
+
+func (v FigureVariant) String() string {
+	if name, ok := figureVariantNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("FigureVariant(%d)", int(v))
+}
+
+// ParseFigureVariant returns the variant with the given name, e.g. "T180".
+func ParseFigureVariant(name string) (FigureVariant, error) {
+	for v, n := range figureVariantNames {
+		if n == name {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown figure variant %q", name)
+}
 
+// figureRects returns the non-overlapping rectangles that make up a figure
+// of baseSize pixels centered at (cx, cy), or nil for an unknown variant.
+func figureRects(cx, cy int, variant FigureVariant, baseSize int) []image.Rectangle {
 	// Параметри для T-фігури
 	barWidth := baseSize               // Ширина горизонтальної/вертикальної перекладини T або хреста
 	barHeight := baseSize / 3          // Висота/товщина перекладини T
@@ -251,7 +503,7 @@ func drawFigure(t screen.Texture, cx, cy int, variant FigureVariant, figureColor
 	armThickness := baseSize / 3
 
 	var rects []image.Rectangle // Слайс для зберігання прямокутників, що складають фігуру
-	log.Printf("drawFigure: Drawing variant %v at (%d, %d) with baseSize %d", variant, cx, cy, baseSize)
+	log.Printf("figureRects: Computing variant %v at (%d, %d) with baseSize %d", variant, cx, cy, baseSize)
 
 	switch variant {
 	case T0: // Стандартна T
@@ -314,23 +566,10 @@ func drawFigure(t screen.Texture, cx, cy int, variant FigureVariant, figureColor
 		rects = append(rects, image.Rect(vbX1, vbY1, vbX2, hbY1)) // Верхня частина вертикалі
 		rects = append(rects, image.Rect(vbX1, hbY2, vbX2, vbY2)) // Нижня частина вертикалі
 	default:
-		log.Printf("drawFigure: Unknown figure variant: %d", variant)
-		return // Не малюємо нічого для невідомого варіанту
-	}
-
-	// Малюємо всі прямокутники, що складають фігуру
-	log.Printf("drawFigure: Filling %d rectangles with color %+v", len(rects), figureColor)
-	textureBounds := t.Bounds()
-	for i, r := range rects {
-		// Обрізаємо прямокутник межами текстури
-		clippedRect := r.Intersect(textureBounds)
-		if !clippedRect.Empty() {
-			t.Fill(clippedRect, figureColor, screen.Src)
-		} else {
-			log.Printf("drawFigure: Rectangle %d (%+v) is outside texture bounds %+v", i, r, textureBounds)
-		}
+		log.Printf("figureRects: Unknown figure variant: %d", variant)
+		return nil
 	}
-	log.Printf("drawFigure: Finished drawing variant %v", variant)
+	return rects
 }
 
 // Допоміжна функція min для цілих чисел (якщо не використовується math.Min)
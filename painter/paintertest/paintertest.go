@@ -0,0 +1,207 @@
+// Package paintertest runs painter command scripts headlessly and compares
+// the rendered frames with golden PNG images.
+//
+// Goldens are stored as testdata/<name>.png next to the test. Run the tests
+// of the package that uses them with -update to create or refresh them:
+//
+//	go test ./painter/paintertest -update
+//
+// The flag is only defined in packages importing paintertest, so it cannot be
+// passed to go test ./... as a whole.
+package paintertest
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Update makes AssertGolden write the images instead of comparing them.
+var Update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+// Options configure how a script is run and compared.
+type Options struct {
+	Width, Height int     // Canvas size, 400x400 if zero
+	Speed         float64 // Loop speed for waits and animations, 100 if zero
+	Tolerance     uint8   // Maximum difference of a color channel still counted as equal
+	MaxDiff       int     // Number of differing pixels still accepted
+}
+
+func (o Options) withDefaults() Options {
+	if o.Width == 0 {
+		o.Width = 400
+	}
+	if o.Height == 0 {
+		o.Height = 400
+	}
+	if o.Speed == 0 {
+		o.Speed = 100
+	}
+	return o
+}
+
+// receiver keeps the last frame sent by the loop.
+type receiver struct {
+	mu    sync.Mutex
+	count int
+	last  *image.RGBA
+}
+
+func (r *receiver) Update(t screen.Texture) {
+	img := t.(*offscreen.Texture).Image()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count++
+	r.last = img
+}
+
+// Run parses the script with lang.ParseCommands, posts it to a headless
+// painter.Loop as one batch and returns the last frame once the loop is idle.
+// The test fails if the script is invalid or produces no frames.
+func Run(t testing.TB, script string, opts Options) *image.RGBA {
+	t.Helper()
+	opts = opts.withDefaults()
+	ops, err := lang.ParseCommands(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("paintertest: invalid script:\n%v", err)
+	}
+
+	r := &receiver{}
+	loop := painter.NewLoop(r, opts.Width, opts.Height)
+	loop.Speed = opts.Speed
+	loop.Start(offscreen.Screen{})
+	defer loop.Stop()
+	<-loop.Idle() // Початкове оновлення не повинно злитися з командами сценарію
+	r.mu.Lock()
+	initial := r.count
+	r.mu.Unlock()
+
+	loop.Post(painter.OperationList(ops))
+	<-loop.Idle()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == initial {
+		t.Fatalf("paintertest: script produced no frames, is 'update' missing?")
+	}
+	return r.last
+}
+
+// Golden runs the script and compares the result with testdata/<name>.png.
+func Golden(t testing.TB, name, script string, opts Options) {
+	t.Helper()
+	AssertGolden(t, name, Run(t, script, opts), opts)
+}
+
+// AssertGolden compares img with testdata/<name>.png. On mismatch, the
+// actual image and a diff highlighting the differing pixels in red are
+// written to testdata/failed for inspection.
+func AssertGolden(t testing.TB, name string, img image.Image, opts Options) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *Update {
+		if err := writePNG(path, img); err != nil {
+			t.Fatalf("paintertest: updating golden: %v", err)
+		}
+		t.Logf("paintertest: updated %s", path)
+		return
+	}
+
+	want, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("paintertest: reading golden (run with -update to create it): %v", err)
+	}
+	n, diff := Compare(img, want, opts.Tolerance)
+	if n <= opts.MaxDiff {
+		return
+	}
+	failed := filepath.Join("testdata", "failed")
+	gotPath := filepath.Join(failed, name+".got.png")
+	diffPath := filepath.Join(failed, name+".diff.png")
+	if err := writePNG(gotPath, img); err != nil {
+		t.Logf("paintertest: writing actual image: %v", err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Logf("paintertest: writing diff image: %v", err)
+	}
+	t.Errorf("paintertest: %s: %d pixels differ (tolerance %d, allowed %d), see %s and %s",
+		name, n, opts.Tolerance, opts.MaxDiff, gotPath, diffPath)
+}
+
+// Compare counts the pixels of got and want whose color channels differ by
+// more than tolerance. The diff image shows them in red over a faded copy of
+// want. Images of different sizes differ in every pixel of their union.
+func Compare(got, want image.Image, tolerance uint8) (int, *image.RGBA) {
+	bounds := got.Bounds().Union(want.Bounds())
+	diff := image.NewRGBA(bounds)
+	n := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			if !p.In(got.Bounds()) || !p.In(want.Bounds()) {
+				diff.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+				n++
+				continue
+			}
+			g := color.RGBAModel.Convert(got.At(x, y)).(color.RGBA)
+			w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+			if channelDiff(g.R, w.R) > tolerance || channelDiff(g.G, w.G) > tolerance ||
+				channelDiff(g.B, w.B) > tolerance || channelDiff(g.A, w.A) > tolerance {
+				diff.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+				n++
+				continue
+			}
+			gray := uint8((uint32(w.R) + uint32(w.G) + uint32(w.B)) / 3)
+			faded := 0xc0 + gray/4
+			diff.Set(x, y, color.RGBA{R: faded, G: faded, B: faded, A: 0xff})
+		}
+	}
+	return n, diff
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
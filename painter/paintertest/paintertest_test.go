@@ -0,0 +1,131 @@
+package paintertest_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/paintertest"
+)
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"white", "white\nupdate"},
+		{"bgrect", "green\nbgrect 0.1 0.1 0.6 0.4\nupdate"},
+		{"figures", `white
+figure 0.3 0.3
+figure 0.7 0.7
+update`},
+		{"move", `white
+bgrect 0.05 0.05 0.3 0.3
+figure 0.3 0.3
+move 0.2 0.1
+update`},
+		{"animate", `green
+figure 0.2 0.5
+animate-move 0.5 0 200ms ease-in-out`},
+		{"shapes", `white
+circle 0.3 0.3 0.15 #0000ff
+ellipse 0.7 0.3 0.2 0.1
+line 0.1 0.9 0.9 0.6 5 #000000
+polygon #00aa00 0.5 0.5 0.9 0.9 0.1 0.9
+update`},
+		{"rotate", `white
+figure 0.3 0.7
+rotate 1 30
+rotate 2 -90
+update`},
+		{"sizes", `white
+figure 0.2 0.2 0.1
+figure 0.7 0.3 0.5
+scale 1 0.5
+scale 2 2
+update`},
+		{"antialias", `white
+antialias on
+circle 0.3 0.3 0.15 #0000ff
+polygon #00aa00 0.5 0.5 0.9 0.9 0.1 0.9
+figure 0.7 0.3
+rotate 4 30
+update`},
+		{"opacity", `white
+bgrect 0.1 0.1 0.6 0.6 0.5
+circle 0.4 0.4 0.2 #0000ff80
+circle 0.6 0.4 0.2 #ff000080
+figure 0.6 0.7
+opacity 1 0.3
+opacity 4 0.6
+update`},
+		{"gradient", `bg-gradient linear #fff #000 45
+bgrect 0.1 0.1 0.4 0.4
+update`},
+		{"radial", `bg-gradient radial #ffff00 #0000ff
+update`},
+		{"checker", `bg-pattern checker #fff #ccc 0.1
+update`},
+		{"stripes", `bg-pattern stripes #ff0000 #ffffff 0.05 30
+update`},
+		{"text", `white
+text 0.05 0.05 "Variant 23"
+text 0.35 0.15 "T-180" 32 #0000ff
+figure 0.3 0.7 0.2
+text 0.2 0.85 "figure #4" 20 #00000080
+update`},
+		{"layers", `white
+layer overlay
+circle 0.5 0.5 0.25 #0000ff
+text 0.05 0.05 "overlay" 24
+layer labels
+text 0.05 0.9 "hidden label" 24
+layer-hide labels
+layer-move overlay bottom
+update`},
+		{"groups", `white
+circle 0.3 0.3 0.1 #ff0000
+circle 0.7 0.3 0.1 #ff0000
+text 0.2 0.5 "grouped" 24
+group pair 2 3 4
+group-move pair 0 0.3
+group-color pair #0000ff
+move -0.2 0
+update`},
+		{"reset", `white
+figure 0.5 0.5
+update
+reset
+update`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paintertest.Golden(t, tt.name, tt.script, paintertest.Options{})
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	got := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	got.Set(1, 1, color.RGBA{R: 3, A: 3})
+	got.Set(2, 2, color.RGBA{G: 0xff, A: 0xff})
+
+	if n, _ := paintertest.Compare(got, want, 0); n != 2 {
+		t.Errorf("expected 2 differing pixels without tolerance, got %d", n)
+	}
+	n, diff := paintertest.Compare(got, want, 5)
+	if n != 1 {
+		t.Errorf("expected 1 differing pixel with tolerance 5, got %d", n)
+	}
+	if c := diff.RGBAAt(2, 2); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("expected differing pixel to be red in the diff, got %v", c)
+	}
+	if c := diff.RGBAAt(1, 1); c.R != c.G {
+		t.Errorf("expected equal pixel to be gray in the diff, got %v", c)
+	}
+
+	if n, _ := paintertest.Compare(image.NewRGBA(image.Rect(0, 0, 4, 5)), want, 0); n != 4 {
+		t.Errorf("expected the extra row to differ, got %d pixels", n)
+	}
+}
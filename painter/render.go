@@ -0,0 +1,349 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"math"
+	"sort"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Renderer is a drawing target for a scene. Fills are composited over what
+// is under them (Porter-Duff "over"), so translucent colors blend with the
+// elements drawn before.
+type Renderer interface {
+	// Bounds returns the drawable area in pixels.
+	Bounds() image.Rectangle
+	// Clear replaces the whole drawable area with the color.
+	Clear(c color.Color)
+	// FillBackground replaces the whole drawable area with the gradient or pattern.
+	FillBackground(f *BgFillOp)
+	// FillRect fills the rectangle with the color.
+	FillRect(r image.Rectangle, c color.Color)
+	// FillPolygon fills the closed polygon with the color using the even-odd rule.
+	FillPolygon(pts []image.Point, c color.Color)
+	// FillEllipse fills the axis-aligned ellipse with the given center and radii.
+	FillEllipse(center image.Point, rx, ry int, c color.Color)
+	// DrawImage draws the image scaled to fill the rectangle.
+	DrawImage(r image.Rectangle, img image.Image)
+	// FillText draws a line of text in the embedded font of the given pixel
+	// size, starting at the left end of the baseline.
+	FillText(origin image.Point, text string, size float64, c color.Color)
+}
+
+// FigureMarker is implemented by renderers that mark which of the drawn
+// shapes form one figure, e.g. SVGRenderer wraps them in a g element.
+type FigureMarker interface {
+	BeginFigure(variant FigureVariant)
+	EndFigure()
+}
+
+// Render draws the state with r. It mirrors what the window shows after
+// UpdateOp: the background, the background rectangle and then the visible
+// layers bottom-up.
+func Render(r Renderer, s State) {
+	// 1. Заливаємо все кольором фону або градієнтом чи візерунком
+	if s.BgFill != nil {
+		r.FillBackground(s.BgFill)
+	} else if s.BgColor != nil {
+		r.Clear(s.BgColor)
+	}
+
+	// 2. Фоновий прямокутник: чорна заливка із зеленою рамкою з урахуванням непрозорості
+	if s.BgRect != nil {
+		rect := image.Rect(s.BgRect.X1, s.BgRect.Y1, s.BgRect.X2, s.BgRect.Y2)
+		r.FillRect(rect, withOpacity(bgRectFillColor, s.BgRect.opacity()))
+		border := withOpacity(bgRectBorderColor, s.BgRect.opacity())
+		for _, b := range bgRectBorder(rect) {
+			r.FillRect(b, border)
+		}
+	}
+
+	// 3. Шари знизу вгору; всередині шару фігури, примітиви, зображення і текст
+	for _, layer := range s.layers() {
+		if !layer.Hidden {
+			renderLayer(r, s, elementLayer(layer.Name))
+		}
+	}
+}
+
+// renderLayer draws the elements of one layer: the figures, the primitive
+// shapes, the sprites and then the text labels. Each element is shifted by
+// the move offset of its group and skipped if the group is hidden.
+func renderLayer(r Renderer, s State, layer string) {
+	// Фігури з урахуванням кумулятивного зміщення від команд 'move' та 'group-move'
+	marker, _ := r.(FigureMarker)
+	for _, fig := range s.Figures {
+		if fig.Layer != layer || !s.visible(fig.Group) {
+			continue
+		}
+		offset := s.offset(fig.Group)
+		center := image.Pt(fig.X, fig.Y).Add(offset)
+		rects := fig.rects(offset, s.WindowWidth, s.WindowHeight)
+		if len(rects) == 0 {
+			continue
+		}
+		if marker != nil {
+			marker.BeginFigure(fig.Variant)
+		}
+		for _, rect := range rects {
+			if fig.Angle == 0 {
+				r.FillRect(rect, fig.Color)
+			} else {
+				// Повернута фігура складається з повернутих прямокутників
+				r.FillPolygon(rotateRect(rect, center, fig.Angle), fig.Color)
+			}
+		}
+		if marker != nil {
+			marker.EndFigure()
+		}
+	}
+
+	// Примітиви (кола, еліпси, відрізки, багатокутники) в порядку додавання
+	for _, sh := range s.Shapes {
+		if sh.Layer == layer && s.visible(sh.Group) {
+			sh.draw(r, s.offset(sh.Group))
+		}
+	}
+
+	// Зображення, завантажені через HTTP
+	for _, sp := range s.Sprites {
+		if sp.Layer == layer && s.visible(sp.Group) {
+			sp.draw(r, s.offset(sp.Group))
+		}
+	}
+
+	// Текстові мітки поверх усього в шарі
+	for _, tx := range s.Texts {
+		if tx.Layer == layer && s.visible(tx.Group) {
+			tx.draw(r, s.offset(tx.Group))
+		}
+	}
+}
+
+// rotateRect returns the corners of r rotated clockwise by deg degrees around c.
+func rotateRect(r image.Rectangle, c image.Point, deg float64) []image.Point {
+	corners := []image.Point{r.Min, {X: r.Max.X, Y: r.Min.Y}, r.Max, {X: r.Min.X, Y: r.Max.Y}}
+	for i, p := range corners {
+		corners[i] = rotatePoint(p, c, deg)
+	}
+	return corners
+}
+
+// rotatePoint rotates p clockwise by deg degrees around c.
+func rotatePoint(p, c image.Point, deg float64) image.Point {
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	dx, dy := float64(p.X-c.X), float64(p.Y-c.Y)
+	// Вісь y спрямована вниз, тож додатний кут повертає за годинниковою стрілкою
+	return image.Pt(c.X+int(math.Round(dx*cos-dy*sin)), c.Y+int(math.Round(dx*sin+dy*cos)))
+}
+
+// TextureRenderer draws on a shiny texture.
+type TextureRenderer struct {
+	T screen.Texture
+}
+
+func (tr TextureRenderer) Bounds() image.Rectangle { return tr.T.Bounds() }
+
+func (tr TextureRenderer) Clear(c color.Color) {
+	tr.T.Fill(tr.T.Bounds(), c, screen.Src)
+}
+
+// FillBackground fills the texture row by row with runs of equal color.
+// It is slow for gradients across rows, so the loop renders such
+// backgrounds into a buffer instead (see Loop.renderBuffered).
+func (tr TextureRenderer) FillBackground(f *BgFillOp) {
+	b := tr.T.Bounds()
+	img := f.image(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		start, c := b.Min.X, img.At(b.Min.X, y)
+		for x := b.Min.X + 1; x <= b.Max.X; x++ {
+			var next color.Color
+			if x < b.Max.X {
+				if next = img.At(x, y); next == c {
+					continue
+				}
+			}
+			tr.T.Fill(image.Rect(start, y, x, y+1), c, screen.Src)
+			start, c = x, next
+		}
+	}
+}
+
+func (tr TextureRenderer) FillRect(r image.Rectangle, c color.Color) {
+	// Обрізаємо прямокутник межами текстури
+	if r = r.Intersect(tr.T.Bounds()); !r.Empty() {
+		tr.T.Fill(r, c, screen.Over)
+	}
+}
+
+func (tr TextureRenderer) FillPolygon(pts []image.Point, c color.Color) {
+	for _, span := range polygonSpans(pts, tr.T.Bounds()) {
+		tr.T.Fill(span, c, screen.Over)
+	}
+}
+
+func (tr TextureRenderer) FillEllipse(center image.Point, rx, ry int, c color.Color) {
+	for _, span := range ellipseSpans(center, rx, ry, tr.T.Bounds()) {
+		tr.T.Fill(span, c, screen.Over)
+	}
+}
+
+// FillText fills runs of pixels of equal coverage, since a texture cannot
+// be drawn through a mask.
+func (tr TextureRenderer) FillText(origin image.Point, text string, size float64, c color.Color) {
+	mask := textMask(text, size)
+	clip := tr.T.Bounds().Sub(origin).Intersect(mask.Rect)
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		for x := clip.Min.X; x < clip.Max.X; {
+			a := mask.AlphaAt(x, y).A
+			start := x
+			for x < clip.Max.X && mask.AlphaAt(x, y).A == a {
+				x++
+			}
+			if a == 0 {
+				continue
+			}
+			// Покриття маски множиться на альфу кольору
+			covered := n
+			covered.A = uint8((uint32(n.A)*uint32(a) + 0x7f) / 0xff)
+			tr.T.Fill(image.Rect(start, y, x, y+1).Add(origin), covered, screen.Over)
+		}
+	}
+}
+
+// DrawImage fills runs of pixels of equal color of the scaled image. It is
+// slow for photos, so the loop renders frames with sprites into a buffer
+// instead (see Loop.renderBuffered).
+func (tr TextureRenderer) DrawImage(r image.Rectangle, img image.Image) {
+	scaled := scaleImage(img, r.Size())
+	clip := tr.T.Bounds().Sub(r.Min).Intersect(scaled.Rect)
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		for x := clip.Min.X; x < clip.Max.X; {
+			c := scaled.RGBAAt(x, y)
+			start := x
+			for x < clip.Max.X && scaled.RGBAAt(x, y) == c {
+				x++
+			}
+			if c.A != 0 {
+				tr.T.Fill(image.Rect(start, y, x, y+1).Add(r.Min), c, screen.Over)
+			}
+		}
+	}
+}
+
+// ImageRenderer draws on an in-memory image, e.g. an *image.RGBA
+// to be encoded as PNG.
+type ImageRenderer struct {
+	Img draw.Image
+}
+
+func (ir ImageRenderer) Bounds() image.Rectangle { return ir.Img.Bounds() }
+
+func (ir ImageRenderer) Clear(c color.Color) {
+	draw.Draw(ir.Img, ir.Img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (ir ImageRenderer) FillBackground(f *BgFillOp) {
+	draw.Draw(ir.Img, ir.Img.Bounds(), f.image(ir.Img.Bounds()), ir.Img.Bounds().Min, draw.Src)
+}
+
+func (ir ImageRenderer) FillRect(r image.Rectangle, c color.Color) {
+	draw.Draw(ir.Img, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func (ir ImageRenderer) FillPolygon(pts []image.Point, c color.Color) {
+	src := image.NewUniform(c)
+	for _, span := range polygonSpans(pts, ir.Img.Bounds()) {
+		draw.Draw(ir.Img, span, src, image.Point{}, draw.Over)
+	}
+}
+
+func (ir ImageRenderer) FillEllipse(center image.Point, rx, ry int, c color.Color) {
+	src := image.NewUniform(c)
+	for _, span := range ellipseSpans(center, rx, ry, ir.Img.Bounds()) {
+		draw.Draw(ir.Img, span, src, image.Point{}, draw.Over)
+	}
+}
+
+func (ir ImageRenderer) DrawImage(r image.Rectangle, img image.Image) {
+	scaled := scaleImage(img, r.Size())
+	draw.Draw(ir.Img, r, scaled, image.Point{}, draw.Over)
+}
+
+func (ir ImageRenderer) FillText(origin image.Point, text string, size float64, c color.Color) {
+	mask := textMask(text, size)
+	draw.DrawMask(ir.Img, mask.Rect.Add(origin), image.NewUniform(c), image.Point{}, mask, mask.Rect.Min, draw.Over)
+}
+
+// withOpacity returns c with its alpha replaced by opacity in [0, 1].
+func withOpacity(c color.Color, opacity float64) color.NRGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(math.Round(math.Max(0, math.Min(1, opacity)) * 0xff))
+	return n
+}
+
+// polygonSpans rasterises the polygon into one pixel high horizontal spans
+// within clip. A pixel belongs to the polygon if its center does.
+func polygonSpans(pts []image.Point, clip image.Rectangle) []image.Rectangle {
+	if len(pts) < 3 {
+		if len(pts) > 0 {
+			log.Printf("polygonSpans: Ignoring polygon with %d points", len(pts))
+		}
+		return nil
+	}
+	var box image.Rectangle
+	for _, p := range pts {
+		box = box.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	box = box.Intersect(clip)
+
+	var spans []image.Rectangle
+	xs := make([]float64, 0, len(pts))
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		fy := float64(y) + 0.5
+		xs = xs[:0]
+		for i, a := range pts {
+			b := pts[(i+1)%len(pts)]
+			// Ребро перетинає горизонталь через центри пікселів рядка
+			if (float64(a.Y) <= fy) != (float64(b.Y) <= fy) {
+				xs = append(xs, float64(a.X)+(fy-float64(a.Y))*float64(b.X-a.X)/float64(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			// Пікселі x, центри яких x+0.5 лежать у [xs[i], xs[i+1])
+			x1, x2 := int(math.Ceil(xs[i]-0.5)), int(math.Ceil(xs[i+1]-0.5))
+			if span := image.Rect(x1, y, x2, y+1).Intersect(clip); !span.Empty() {
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans
+}
+
+// ellipseSpans rasterises the ellipse into one pixel high horizontal spans
+// within clip. A pixel belongs to the ellipse if its center does.
+func ellipseSpans(center image.Point, rx, ry int, clip image.Rectangle) []image.Rectangle {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	cx, cy := float64(center.X), float64(center.Y)
+	var spans []image.Rectangle
+	for y := max(center.Y-ry, clip.Min.Y); y < min(center.Y+ry, clip.Max.Y); y++ {
+		dy := (float64(y) + 0.5 - cy) / float64(ry)
+		if dy*dy >= 1 {
+			continue
+		}
+		half := float64(rx) * math.Sqrt(1-dy*dy)
+		x1, x2 := int(math.Ceil(cx-half-0.5)), int(math.Ceil(cx+half-0.5))
+		if span := image.Rect(x1, y, x2, y+1).Intersect(clip); !span.Empty() {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
@@ -0,0 +1,224 @@
+package painter_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_ImageMatchesTexture(t *testing.T) {
+	state := painter.State{
+		BgColor: color.White,
+		BgRect:  &painter.BgRectOp{X1: 20, Y1: 20, X2: 120, Y2: 80},
+		Figures: []*painter.FigureOp{
+			{X: 100, Y: 100, Variant: painter.T90, Color: color.NRGBA{R: 0xff, A: 0xff}},
+			{X: 190, Y: 10, Variant: painter.Cross, Color: color.NRGBA{B: 0xff, A: 0xff}},
+		},
+		MoveOffset:   image.Pt(5, 5),
+		WindowWidth:  200,
+		WindowHeight: 200,
+	}
+	texture := offscreen.NewTexture(image.Pt(200, 200))
+	s := state
+	assert.True(t, painter.UpdateOp{}.Do(&s, texture))
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	painter.Render(painter.ImageRenderer{Img: img}, state)
+
+	assert.True(t, bytes.Equal(texture.Image().Pix, img.Pix), "UpdateOp and ImageRenderer output differ")
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.RGBAAt(105, 105))
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.RGBAAt(20, 50))
+}
+
+func TestImageRenderer_FillPolygon(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	red := color.RGBA{R: 0xff, A: 0xff}
+	// Прямокутний трикутник із катетами вздовж осей
+	painter.ImageRenderer{Img: img}.FillPolygon([]image.Point{{0, 0}, {10, 0}, {0, 10}}, red)
+
+	filled := 0
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			inside := x+y < 9 // центр пікселя (x+0.5, y+0.5) лежить під гіпотенузою
+			assert.Equal(t, inside, img.RGBAAt(x, y) == red, "pixel (%d, %d)", x, y)
+			if inside {
+				filled++
+			}
+		}
+	}
+	assert.Equal(t, 45, filled)
+
+	// Полігон, що виходить за межі зображення, обрізається
+	painter.ImageRenderer{Img: img}.FillPolygon([]image.Point{{-5, -5}, {20, -5}, {20, 20}, {-5, 20}}, red)
+	assert.Equal(t, red, img.RGBAAt(9, 9))
+}
+
+func TestRender_Shapes(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	red, blue := color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0xff}
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.2, Color: red}.Do(&s, nil)
+	painter.Line{X1: 0, Y1: 0.9, X2: 1, Y2: 0.9, Thickness: 3, Color: blue}.Do(&s, nil)
+	painter.Move{X: 0.1, Y: 0}.Do(&s, nil)
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+
+	rgba := func(c color.NRGBA) color.RGBA { return color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A} }
+	// Коло радіусом 20 з центром у (60, 50) після зміщення
+	assert.Equal(t, rgba(red), img.RGBAAt(60, 50))
+	assert.Equal(t, rgba(red), img.RGBAAt(41, 50))
+	assert.Equal(t, rgba(red), img.RGBAAt(60, 69))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.RGBAAt(45, 35))
+	// Горизонтальна лінія товщиною 3 пікселі
+	for y := 87; y < 93; y++ {
+		assert.Equal(t, y >= 89 && y <= 91, img.RGBAAt(50, y) == rgba(blue), "line pixel at y=%d", y)
+	}
+}
+
+func TestRender_RotatedFigure(t *testing.T) {
+	render := func(variant painter.FigureVariant, angle float64) *image.RGBA {
+		s := painter.State{BgColor: color.White, WindowWidth: 400, WindowHeight: 400}
+		painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)
+		s.Figures[0].Variant = variant
+		painter.Rotate{ID: s.Figures[0].ID, Angle: angle}.Do(&s, nil)
+		img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+		painter.Render(painter.ImageRenderer{Img: img}, s)
+		return img
+	}
+	// Пресети залишаються: T0, повернута на 180°, збігається з T180
+	assert.Equal(t, render(painter.T180, 0).Pix, render(painter.T0, -180).Pix)
+
+	rotated := render(painter.Cross, 45)
+	yellow := color.RGBA{R: 0xff, G: 0xff, A: 0xff}
+	assert.Equal(t, yellow, rotated.RGBAAt(200, 200))
+	assert.Equal(t, yellow, rotated.RGBAAt(235, 235)) // Рука хреста вздовж діагоналі
+	assert.NotEqual(t, yellow, rotated.RGBAAt(255, 200))
+}
+
+func TestRotate_UnknownFigure(t *testing.T) {
+	s := painter.State{WindowWidth: 100, WindowHeight: 100}
+	painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.1}.Do(&s, nil)
+	assert.Equal(t, 1, s.Figures[0].ID)
+	assert.Equal(t, 2, s.Shapes[0].ID)
+
+	before := s.Figures[0]
+	painter.Rotate{ID: 1, Angle: 450}.Do(&s, nil)
+	painter.Rotate{ID: 2, Angle: 10}.Do(&s, nil) // Примітиви не обертаються
+	assert.Equal(t, 90.0, s.Figures[0].Angle)
+	assert.Equal(t, 0.0, before.Angle, "rotation must not modify shared figure copies")
+}
+
+func TestState_FigureAt(t *testing.T) {
+	s := painter.State{WindowWidth: 400, WindowHeight: 400}
+	painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)             // T180 розміром 120 пікселів
+	painter.Figure{X: 0.5, Y: 0.5, Size: 0.05}.Do(&s, nil) // Маленька фігура поверх першої
+	painter.Scale{ID: 1, Factor: 0.5}.Do(&s, nil)          // Тепер 60 пікселів
+
+	assert.Equal(t, 0.15, s.Figures[0].Size)
+	assert.Equal(t, 2, s.FigureAt(image.Pt(200, 200)).ID)
+	assert.Equal(t, 1, s.FigureAt(image.Pt(200, 180)).ID) // Ніжка першої фігури
+	assert.Nil(t, s.FigureAt(image.Pt(200, 235)))         // Була б у межах фігури розміром 120
+
+	// Перекладина T180 знизу; після повороту на 90° вона зліва
+	assert.Equal(t, 1, s.FigureAt(image.Pt(225, 220)).ID)
+	painter.Rotate{ID: 1, Angle: 90}.Do(&s, nil)
+	assert.Nil(t, s.FigureAt(image.Pt(225, 220)))
+	assert.Equal(t, 1, s.FigureAt(image.Pt(175, 220)).ID)
+
+	painter.Move{X: 0.25, Y: 0}.Do(&s, nil)
+	assert.Equal(t, 1, s.FigureAt(image.Pt(275, 220)).ID)
+
+	// Масштабування не виводить розмір за межі, які приймає команда figure
+	painter.Scale{ID: 1, Factor: 100}.Do(&s, nil)
+	assert.Equal(t, painter.MaxFigureSize, s.Figures[0].Size)
+	painter.Scale{ID: 2, Factor: 0.001}.Do(&s, nil)
+	assert.Equal(t, painter.MinFigureSize, s.Figures[1].Size)
+}
+
+func TestAntialiasRenderer_FillPolygon(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	white, red := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.RGBA{R: 0xff, A: 0xff}
+	ar := &painter.AntialiasRenderer{Img: img}
+	ar.FillRect(img.Bounds(), white)
+	ar.FillPolygon([]image.Point{{0, 0}, {10, 0}, {0, 10}}, red)
+
+	assert.Equal(t, red, img.RGBAAt(0, 0))
+	assert.Equal(t, white, img.RGBAAt(9, 9))
+	// Гіпотенуза ділить піксель (4, 5) навпіл, тож він наполовину червоний
+	edge := img.RGBAAt(4, 5)
+	assert.Equal(t, uint8(0xff), edge.R)
+	assert.InDelta(t, 0x80, int(edge.G), 8)
+	assert.Equal(t, edge.G, edge.B)
+}
+
+func TestAntialiasRenderer_FillEllipseAcrossEdge(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	white, red := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.RGBA{R: 0xff, A: 0xff}
+	ar := &painter.AntialiasRenderer{Img: img}
+	ar.FillRect(img.Bounds(), white)
+	ar.FillEllipse(image.Pt(-10, 50), 30, 30, red)
+
+	// У рядку 25 край кола проходить між x ≈ 6.6 і x ≈ 7.3, тож пікселі 0..5
+	// зафарбовані повністю, а 6 і 7 частково
+	for x := 0; x <= 5; x++ {
+		assert.Equal(t, red, img.RGBAAt(x, 25), "x=%d", x)
+	}
+	assert.Less(t, img.RGBAAt(6, 25).G, uint8(0x40))
+	assert.Equal(t, white, img.RGBAAt(8, 25))
+	assert.Equal(t, white, img.RGBAAt(0, 15))
+	assert.Equal(t, red, img.RGBAAt(18, 50))
+}
+
+func TestRenderImage_Antialias(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.3, Color: color.NRGBA{B: 0xff, A: 0xff}}.Do(&s, nil)
+
+	partial := func(img *image.RGBA) int {
+		n := 0
+		for i := 0; i < len(img.Pix); i += 4 {
+			if g := img.Pix[i+1]; g != 0 && g != 0xff {
+				n++
+			}
+		}
+		return n
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	painter.RenderImage(img, s)
+	assert.Zero(t, partial(img), "edges must be hard without anti-aliasing")
+
+	painter.Antialias{Enabled: true}.Do(&s, nil)
+	painter.RenderImage(img, s)
+	assert.Greater(t, partial(img), 50, "the circle edge must be blended with the background")
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, img.RGBAAt(50, 50))
+}
+
+func TestRender_Opacity(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	painter.BgRect{X1: 0, Y1: 0, X2: 0.5, Y2: 1, Opacity: 0.5}.Do(&s, nil)
+	painter.Circle{X: 0.5, Y: 0.5, R: 0.2, Color: color.NRGBA{B: 0xff, A: 0xff}}.Do(&s, nil)
+	painter.Figure{X: 0.8, Y: 0.2, Size: 0.2}.Do(&s, nil)
+	shared := s.Shapes[0]
+	painter.Opacity{ID: 1, Value: 0.5}.Do(&s, nil)
+	painter.Opacity{ID: 2, Value: 0}.Do(&s, nil)
+	assert.Equal(t, uint8(0xff), color.NRGBAModel.Convert(shared.Color).(color.NRGBA).A, "opacity must not modify shared shape copies")
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+	texture := offscreen.NewTexture(image.Pt(100, 100))
+	painter.Render(painter.TextureRenderer{T: texture}, s)
+	assert.True(t, bytes.Equal(texture.Image().Pix, img.Pix), "texture and image blending differ")
+
+	// Напівпрозорий чорний прямокутник над білим фоном дає сірий
+	assert.InDelta(t, 0x80, int(img.RGBAAt(10, 10).R), 1)
+	// Напівпрозоре синє коло змішується з фоном і з прямокутником під ним
+	assert.Equal(t, color.RGBA{R: 0x7f, G: 0x7f, B: 0xff, A: 0xff}, img.RGBAAt(60, 50))
+	assert.Equal(t, color.RGBA{R: 0x3f, G: 0x3f, B: 0xbf, A: 0xff}, img.RGBAAt(40, 50))
+	// Повністю прозора фігура не малюється
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.RGBAAt(80, 20))
+}
@@ -0,0 +1,418 @@
+package painter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// SceneVersion is the version of the scene file format written by SaveScene.
+const SceneVersion = 1
+
+// ScenesDir is the directory where scenes are saved and loaded from.
+var ScenesDir = "scenes"
+
+// Scene is the on-disk form of a saved State.
+type Scene struct {
+	Version int      `json:"version"`
+	State   Snapshot `json:"state"`
+}
+
+var (
+	// ErrSceneNotFound is returned by LoadScene if there is no scene with the name.
+	ErrSceneNotFound = errors.New("scene not found")
+	// ErrInvalidScene wraps errors about invalid scene names and files.
+	ErrInvalidScene = errors.New("invalid scene")
+)
+
+var sceneNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidSceneName checks that the name can be safely used as a file name.
+func ValidSceneName(name string) error {
+	if !sceneNameRe.MatchString(name) {
+		return fmt.Errorf("%w name %q: use up to 64 letters, digits, '-' or '_'", ErrInvalidScene, name)
+	}
+	return nil
+}
+
+func scenePath(name string) (string, error) {
+	if err := ValidSceneName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(ScenesDir, name+".json"), nil
+}
+
+// SaveScene writes the state to ScenesDir under the given name.
+// The file is replaced atomically, so a failed save keeps the previous version.
+func SaveScene(name string, s State) error {
+	path, err := scenePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(Scene{Version: SceneVersion, State: s.Snapshot()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ScenesDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(ScenesDir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadScene reads and validates the scene with the given name.
+func LoadScene(name string) (Snapshot, error) {
+	path, err := scenePath(name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrSceneNotFound, name)
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return DecodeScene(data)
+}
+
+// DecodeScene parses and validates the contents of a scene file.
+func DecodeScene(data []byte) (Snapshot, error) {
+	var scene Scene
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&scene); err != nil {
+		return Snapshot{}, fmt.Errorf("%w file: %v", ErrInvalidScene, err)
+	}
+	if scene.Version != SceneVersion {
+		return Snapshot{}, fmt.Errorf("%w: unsupported version %d, expected %d", ErrInvalidScene, scene.Version, SceneVersion)
+	}
+	if _, err := scene.State.toState(1, 1); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidScene, err)
+	}
+	return scene.State, nil
+}
+
+// ListScenes returns the names of all saved scenes in ScenesDir.
+func ListScenes() ([]string, error) {
+	entries, err := os.ReadDir(ScenesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() && ValidSceneName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// toFill converts the background fill of a snapshot.
+func (f SnapshotFill) toFill() (*BgFillOp, error) {
+	kind, err := ParseBgFillKind(f.Kind)
+	if err != nil {
+		return nil, err
+	}
+	from, err := ParseColor(f.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ParseColor(f.To)
+	if err != nil {
+		return nil, err
+	}
+	if f.Size != 0 && (f.Size < MinPatternSize || f.Size > MaxPatternSize) {
+		return nil, fmt.Errorf("size %g out of range [%g, %g]", f.Size, MinPatternSize, MaxPatternSize)
+	}
+	return &BgFillOp{Kind: kind, From: from, To: to, Angle: f.Angle, Size: f.Size}, nil
+}
+
+// toState converts the snapshot back to a State of the given window size.
+// Pixel coordinates are rescaled if the snapshot was taken at another size.
+func (snap Snapshot) toState(width, height int) (State, error) {
+	if snap.Width <= 0 || snap.Height <= 0 {
+		return State{}, fmt.Errorf("invalid canvas size %dx%d", snap.Width, snap.Height)
+	}
+	sx := func(x int) int { return x * width / snap.Width }
+	sy := func(y int) int { return y * height / snap.Height }
+
+	bg, err := ParseColor(snap.Background)
+	if err != nil {
+		return State{}, fmt.Errorf("background: %w", err)
+	}
+	s := State{
+		BgColor:      bg,
+		Figures:      make([]*FigureOp, 0, len(snap.Figures)),
+		MoveOffset:   image.Pt(sx(snap.Offset.X), sy(snap.Offset.Y)),
+		WindowWidth:  width,
+		WindowHeight: height,
+		Antialias:    snap.Antialias,
+	}
+	if f := snap.BgFill; f != nil {
+		if s.BgFill, err = f.toFill(); err != nil {
+			return State{}, fmt.Errorf("bgFill: %w", err)
+		}
+	}
+	if r := snap.BgRect; r != nil {
+		if r.X1 > r.X2 || r.Y1 > r.Y2 {
+			return State{}, fmt.Errorf("bgRect: corners are not ordered")
+		}
+		if r.Opacity < 0 || r.Opacity > 1 {
+			return State{}, fmt.Errorf("bgRect: opacity %g out of range [0, 1]", r.Opacity)
+		}
+		s.BgRect = &BgRectOp{X1: sx(r.X1), Y1: sy(r.Y1), X2: sx(r.X2), Y2: sy(r.Y2), Opacity: r.Opacity}
+	}
+	layers := map[string]bool{}
+	for i, l := range snap.Layers {
+		if err := ValidLayerName(l.Name); err != nil {
+			return State{}, fmt.Errorf("layer %d: %w", i, err)
+		}
+		if layers[l.Name] {
+			return State{}, fmt.Errorf("layer %d: duplicate name %q", i, l.Name)
+		}
+		layers[l.Name] = true
+		s.Layers = append(s.Layers, &LayerOp{Name: l.Name, Hidden: l.Hidden})
+	}
+	// Елементи без шару належать до шару за замовчуванням, який може бути не вказаний
+	layer := func(name string) (string, error) {
+		if name == "" || name == DefaultLayer {
+			if len(layers) > 0 && !layers[DefaultLayer] {
+				return "", fmt.Errorf("no layer %q", DefaultLayer)
+			}
+			return "", nil
+		}
+		if !layers[name] {
+			return "", fmt.Errorf("no layer %q", name)
+		}
+		return name, nil
+	}
+	if s.ActiveLayer, err = layer(snap.Active); err != nil {
+		return State{}, fmt.Errorf("activeLayer: %w", err)
+	}
+	for i, g := range snap.Groups {
+		if err := ValidGroupName(g.Name); err != nil {
+			return State{}, fmt.Errorf("group %d: %w", i, err)
+		}
+		if s.groupIndex(g.Name) >= 0 {
+			return State{}, fmt.Errorf("group %d: duplicate name %q", i, g.Name)
+		}
+		s.Groups = append(s.Groups, &GroupOp{Name: g.Name, Offset: image.Pt(sx(g.Offset.X), sy(g.Offset.Y)), Hidden: g.Hidden})
+	}
+	group := func(name string) error {
+		if name != "" && s.groupIndex(name) < 0 {
+			return fmt.Errorf("no group %q", name)
+		}
+		return nil
+	}
+	for i, f := range snap.Figures {
+		variant, err := ParseFigureVariant(f.Variant)
+		if err != nil {
+			return State{}, fmt.Errorf("figure %d: %w", i, err)
+		}
+		c, err := ParseColor(f.Color)
+		if err != nil {
+			return State{}, fmt.Errorf("figure %d: %w", i, err)
+		}
+		if f.Angle < 0 || f.Angle >= 360 {
+			return State{}, fmt.Errorf("figure %d: angle %g out of range [0, 360)", i, f.Angle)
+		}
+		if f.Size != 0 && (f.Size < MinFigureSize || f.Size > MaxFigureSize) {
+			return State{}, fmt.Errorf("figure %d: size %g out of range [%g, %g]", i, f.Size, MinFigureSize, MaxFigureSize)
+		}
+		l, err := layer(f.Layer)
+		if err != nil {
+			return State{}, fmt.Errorf("figure %d: %w", i, err)
+		}
+		if err := group(f.Group); err != nil {
+			return State{}, fmt.Errorf("figure %d: %w", i, err)
+		}
+		s.Figures = append(s.Figures, &FigureOp{ID: f.ID, X: sx(f.X), Y: sy(f.Y), Variant: variant, Angle: f.Angle, Size: f.Size, Color: c, Layer: l, Group: f.Group})
+	}
+	for i, sh := range snap.Shapes {
+		kind, err := ParseShapeKind(sh.Kind)
+		if err != nil {
+			return State{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		c, err := ParseColor(sh.Color)
+		if err != nil {
+			return State{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		l, err := layer(sh.Layer)
+		if err != nil {
+			return State{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		if err := group(sh.Group); err != nil {
+			return State{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		shape := &ShapeOp{ID: sh.ID, Kind: kind, RX: sx(sh.RX), RY: sy(sh.RY), Thickness: sh.Thickness, Color: c, Layer: l, Group: sh.Group}
+		for _, p := range sh.Points {
+			shape.Points = append(shape.Points, image.Pt(sx(p.X), sy(p.Y)))
+		}
+		if err := shape.validate(); err != nil {
+			return State{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		s.Shapes = append(s.Shapes, shape)
+	}
+	for i, sp := range snap.Sprites {
+		if err := ValidSpriteName(sp.Name); err != nil {
+			return State{}, fmt.Errorf("sprite %d: %w", i, err)
+		}
+		if sp.Width <= 0 || sp.Height <= 0 || sp.Width > MaxSpriteSide || sp.Height > MaxSpriteSide {
+			return State{}, fmt.Errorf("sprite %d: invalid size %dx%d, expected at most %dx%d", i, sp.Width, sp.Height, MaxSpriteSide, MaxSpriteSide)
+		}
+		l, err := layer(sp.Layer)
+		if err != nil {
+			return State{}, fmt.Errorf("sprite %d: %w", i, err)
+		}
+		if err := group(sp.Group); err != nil {
+			return State{}, fmt.Errorf("sprite %d: %w", i, err)
+		}
+		s.Sprites = append(s.Sprites, &SpriteOp{ID: sp.ID, Name: sp.Name, X: sx(sp.X), Y: sy(sp.Y), W: spriteSide(sx(sp.Width)), H: spriteSide(sy(sp.Height)), Layer: l, Group: sp.Group})
+	}
+	for i, tx := range snap.Texts {
+		c, err := ParseColor(tx.Color)
+		if err != nil {
+			return State{}, fmt.Errorf("text %d: %w", i, err)
+		}
+		if err := ValidText(tx.Text, tx.Size); err != nil {
+			return State{}, fmt.Errorf("text %d: %w", i, err)
+		}
+		l, err := layer(tx.Layer)
+		if err != nil {
+			return State{}, fmt.Errorf("text %d: %w", i, err)
+		}
+		if err := group(tx.Group); err != nil {
+			return State{}, fmt.Errorf("text %d: %w", i, err)
+		}
+		s.Texts = append(s.Texts, &TextOp{ID: tx.ID, X: sx(tx.X), Y: sy(tx.Y), Content: tx.Text, Size: tx.Size, Color: c, Layer: l, Group: tx.Group})
+	}
+	if err := s.assignIDs(); err != nil {
+		return State{}, err
+	}
+	return s, nil
+}
+
+// assignIDs checks that figure, shape, sprite and text identifiers are unique, gives new
+// ones to elements without an identifier and sets LastID accordingly.
+func (s *State) assignIDs() error {
+	seen := map[int]bool{}
+	var missing []*int
+	check := func(id *int) error {
+		switch {
+		case *id < 0:
+			return fmt.Errorf("negative id %d", *id)
+		case *id == 0:
+			missing = append(missing, id)
+		case seen[*id]:
+			return fmt.Errorf("duplicate id %d", *id)
+		}
+		seen[*id] = true
+		s.LastID = max(s.LastID, *id)
+		return nil
+	}
+	for _, f := range s.Figures {
+		if err := check(&f.ID); err != nil {
+			return err
+		}
+	}
+	for _, sh := range s.Shapes {
+		if err := check(&sh.ID); err != nil {
+			return err
+		}
+	}
+	for _, sp := range s.Sprites {
+		if err := check(&sp.ID); err != nil {
+			return err
+		}
+	}
+	for _, tx := range s.Texts {
+		if err := check(&tx.ID); err != nil {
+			return err
+		}
+	}
+	// Сцени, збережені до появи ідентифікаторів, отримують їх у порядку малювання
+	for _, id := range missing {
+		*id = s.newID()
+	}
+	return nil
+}
+
+// Save defines the operation for saving the current state as a named scene.
+type Save struct {
+	Name   string
+	Result chan<- error // Optional buffered channel receiving the outcome of the save
+}
+
+func (op Save) Do(s *State, t screen.Texture) bool {
+	err := SaveScene(op.Name, *s)
+	if err != nil {
+		log.Printf("Save.Do: Failed to save scene %q: %v", op.Name, err)
+	} else {
+		log.Printf("Save.Do: Scene %q saved", op.Name)
+	}
+	if op.Result != nil {
+		op.Result <- err
+	}
+	return false
+}
+
+// Load defines the operation for replacing the state with a saved scene.
+// Like other state changes, it becomes visible after the next update.
+type Load struct {
+	Name string
+}
+
+func (op Load) Do(s *State, t screen.Texture) bool {
+	snap, err := LoadScene(op.Name)
+	if err != nil {
+		log.Printf("Load.Do: Failed to load scene %q: %v", op.Name, err)
+		return false
+	}
+	log.Printf("Load.Do: Scene %q loaded", op.Name)
+	return Restore{Scene: snap}.Do(s, t)
+}
+
+// Restore defines the operation for replacing the state with a snapshot,
+// e.g. one validated in advance by LoadScene.
+type Restore struct {
+	Scene Snapshot
+}
+
+func (op Restore) Do(s *State, t screen.Texture) bool {
+	restored, err := op.Scene.toState(s.WindowWidth, s.WindowHeight)
+	if err != nil {
+		log.Printf("Restore.Do: Invalid scene: %v", err)
+		return false
+	}
+	// Режим згладжування - налаштування відображення, а не частина сцени
+	restored.Antialias = s.Antialias
+	*s = restored
+	log.Printf("Restore.Do: State restored with %d figures", len(s.Figures))
+	return false
+}
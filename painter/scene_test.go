@@ -0,0 +1,96 @@
+package painter_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useScenesDir(t *testing.T) string {
+	dir := t.TempDir()
+	prev := painter.ScenesDir
+	painter.ScenesDir = dir
+	t.Cleanup(func() { painter.ScenesDir = prev })
+	return dir
+}
+
+func TestScene_SaveLoad(t *testing.T) {
+	useScenesDir(t)
+	state := painter.State{
+		BgColor: color.White,
+		BgFill:  &painter.BgFillOp{Kind: painter.Stripes, From: color.NRGBA{A: 0xff}, To: color.NRGBA{R: 0xff, A: 0x80}, Angle: 45, Size: 0.1},
+		BgRect:  &painter.BgRectOp{X1: 10, Y1: 20, X2: 100, Y2: 200, Opacity: 0.5},
+		Figures: []*painter.FigureOp{{X: 400, Y: 400, Variant: painter.Cross, Color: color.RGBA{R: 0xff, G: 0xff, A: 0xff}}},
+		Shapes: []*painter.ShapeOp{
+			{Kind: painter.EllipseShape, Points: []image.Point{{200, 200}}, RX: 100, RY: 40, Color: color.NRGBA{B: 0xff, A: 0xff}},
+			{Kind: painter.LineShape, Points: []image.Point{{0, 0}, {800, 800}}, Thickness: 3, Color: color.Black},
+		},
+		MoveOffset:   image.Pt(40, -80),
+		WindowWidth:  800,
+		WindowHeight: 800,
+	}
+	require.NoError(t, painter.SaveScene("demo", state))
+
+	names, err := painter.ListScenes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"demo"}, names)
+
+	snap, err := painter.LoadScene("demo")
+	require.NoError(t, err)
+	assert.Equal(t, state.Snapshot(), snap)
+
+	// The scene is rescaled to the size of the window it is restored into.
+	restored := painter.State{WindowWidth: 400, WindowHeight: 400}
+	painter.Restore{Scene: snap}.Do(&restored, nil)
+	assert.Equal(t, &painter.BgRectOp{X1: 5, Y1: 10, X2: 50, Y2: 100, Opacity: 0.5}, restored.BgRect)
+	assert.Equal(t, image.Pt(20, -40), restored.MoveOffset)
+	assert.Equal(t, state.BgFill, restored.BgFill)
+	require.Len(t, restored.Figures, 1)
+	assert.Equal(t, 200, restored.Figures[0].X)
+	assert.Equal(t, painter.Cross, restored.Figures[0].Variant)
+	require.Len(t, restored.Shapes, 2)
+	assert.Equal(t, []image.Point{{100, 100}}, restored.Shapes[0].Points)
+	assert.Equal(t, 50, restored.Shapes[0].RX)
+	assert.Equal(t, 3, restored.Shapes[1].Thickness)
+}
+
+func TestScene_LoadErrors(t *testing.T) {
+	dir := useScenesDir(t)
+
+	_, err := painter.LoadScene("missing")
+	assert.ErrorIs(t, err, painter.ErrSceneNotFound)
+	_, err = painter.LoadScene("../etc/passwd")
+	assert.ErrorIs(t, err, painter.ErrInvalidScene)
+
+	files := map[string]string{
+		"version": `{"version": 2, "state": {"background": "#000000", "figures": [], "width": 1, "height": 1}}`,
+		"color":   `{"version": 1, "state": {"background": "black", "figures": [], "width": 1, "height": 1}}`,
+		"variant": `{"version": 1, "state": {"background": "#000000", "figures": [{"variant": "Z", "color": "#000000"}], "width": 1, "height": 1}}`,
+		"figure":  `{"version": 1, "state": {"background": "#000000", "figures": [{"variant": "T180", "size": 50, "color": "#000000"}], "width": 1, "height": 1}}`,
+		"rect":    `{"version": 1, "state": {"background": "#000000", "bgRect": {"x1": 5, "x2": 1}, "width": 1, "height": 1}}`,
+		"opacity": `{"version": 1, "state": {"background": "#000000", "bgRect": {"x1": 0, "x2": 1, "opacity": 2}, "width": 1, "height": 1}}`,
+		"fill":    `{"version": 1, "state": {"background": "#000000", "bgFill": {"kind": "dots", "from": "#000", "to": "#fff"}, "width": 1, "height": 1}}`,
+		"pattern": `{"version": 1, "state": {"background": "#000000", "bgFill": {"kind": "checkerboard", "from": "#000000", "to": "#ffffff", "size": 5}, "width": 1, "height": 1}}`,
+		"size":    `{"version": 1, "state": {"background": "#000000"}}`,
+		"field":   `{"version": 1, "state": {"background": "#000000", "width": 1, "height": 1}, "extra": true}`,
+		"syntax":  `{"version": 1,`,
+		"shape":   `{"version": 1, "state": {"background": "#000000", "shapes": [{"kind": "polygon", "points": [{"x": 1, "y": 1}], "color": "#000000"}], "width": 1, "height": 1}}`,
+		"layer":   `{"version": 1, "state": {"background": "#000000", "layers": [{"name": "top"}], "figures": [{"variant": "T180", "color": "#000000", "layer": "missing"}], "width": 1, "height": 1}}`,
+		"layers":  `{"version": 1, "state": {"background": "#000000", "layers": [{"name": "top"}, {"name": "top"}], "width": 1, "height": 1}}`,
+		"group":   `{"version": 1, "state": {"background": "#000000", "groups": [{"name": "a", "offset": {"x": 0, "y": 0}}], "texts": [{"text": "x", "size": 16, "color": "#000000", "group": "b"}], "width": 1, "height": 1}}`,
+		"text":    `{"version": 1, "state": {"background": "#000000", "texts": [{"text": "x", "size": 1000, "color": "#000000"}], "width": 1, "height": 1}}`,
+		"sprite":  `{"version": 1, "state": {"background": "#000000", "sprites": [{"name": "../logo", "width": 1, "height": 1}], "width": 1, "height": 1}}`,
+		"huge":    `{"version": 1, "state": {"background": "#000000", "sprites": [{"name": "logo", "width": 1000000, "height": 1000000}], "width": 1, "height": 1}}`,
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".json"), []byte(data), 0o644))
+		_, err := painter.LoadScene(name)
+		assert.ErrorIs(t, err, painter.ErrInvalidScene, name)
+	}
+}
@@ -0,0 +1,215 @@
+package painter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// ShapeKind defines the kind of a primitive shape.
+type ShapeKind int
+
+const (
+	CircleShape  ShapeKind = iota // Коло
+	EllipseShape                  // Еліпс
+	LineShape                     // Відрізок заданої товщини
+	PolygonShape                  // Довільний багатокутник
+)
+
+// shapeKindNames maps shape kinds to their names used in snapshots.
+var shapeKindNames = map[ShapeKind]string{CircleShape: "circle", EllipseShape: "ellipse", LineShape: "line", PolygonShape: "polygon"}
+
+func (k ShapeKind) String() string {
+	if name, ok := shapeKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ShapeKind(%d)", int(k))
+}
+
+// ParseShapeKind returns the shape kind with the given name, e.g. "circle".
+func ParseShapeKind(name string) (ShapeKind, error) {
+	for k, n := range shapeKindNames {
+		if n == name {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown shape kind %q", name)
+}
+
+// DefaultShapeColor is used for shapes created without a color.
+var DefaultShapeColor color.Color = color.NRGBA{R: 0xff, A: 0xff}
+
+// ShapeOp represents the state for drawing a primitive shape.
+type ShapeOp struct {
+	ID        int // Ідентифікатор зі спільної з фігурами нумерації
+	Kind      ShapeKind
+	Points    []image.Point // Центр кола чи еліпса, кінці відрізка або вершини багатокутника
+	RX, RY    int           // Радіуси кола та еліпса в пікселях
+	Thickness int           // Товщина відрізка в пікселях
+	Color     color.Color
+	Layer     string // Назва шару, "" для DefaultLayer
+	Group     string // Назва групи, "" якщо примітив не в групі
+}
+
+// updateShape replaces the shape with the given identifier by a modified
+// copy, like updateFigure. It reports whether the shape exists.
+func (s *State) updateShape(id int, update func(sh *ShapeOp)) bool {
+	for i, sh := range s.Shapes {
+		if sh.ID == id {
+			c := *sh
+			update(&c)
+			s.Shapes[i] = &c
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that the shape has the geometry its kind requires.
+func (sh *ShapeOp) validate() error {
+	switch sh.Kind {
+	case CircleShape, EllipseShape:
+		if len(sh.Points) != 1 {
+			return fmt.Errorf("%v needs exactly 1 point, got %d", sh.Kind, len(sh.Points))
+		}
+		if sh.RX < 0 || sh.RY < 0 {
+			return fmt.Errorf("%v has a negative radius", sh.Kind)
+		}
+	case LineShape:
+		if len(sh.Points) != 2 {
+			return fmt.Errorf("line needs exactly 2 points, got %d", len(sh.Points))
+		}
+		if sh.Thickness < 1 {
+			return fmt.Errorf("line thickness must be at least 1, got %d", sh.Thickness)
+		}
+	case PolygonShape:
+		if len(sh.Points) < 3 {
+			return fmt.Errorf("polygon needs at least 3 points, got %d", len(sh.Points))
+		}
+	default:
+		return fmt.Errorf("unknown shape kind %d", int(sh.Kind))
+	}
+	return nil
+}
+
+// draw renders the shape shifted by offset.
+func (sh *ShapeOp) draw(r Renderer, offset image.Point) {
+	pts := make([]image.Point, len(sh.Points))
+	for i, p := range sh.Points {
+		pts[i] = p.Add(offset)
+	}
+	switch sh.Kind {
+	case CircleShape, EllipseShape:
+		r.FillEllipse(pts[0], sh.RX, sh.RY, sh.Color)
+	case LineShape:
+		r.FillPolygon(lineQuad(pts[0], pts[1], sh.Thickness), sh.Color)
+	case PolygonShape:
+		r.FillPolygon(pts, sh.Color)
+	default:
+		log.Printf("ShapeOp.draw: Unknown shape kind: %d", sh.Kind)
+	}
+}
+
+// lineQuad returns the rectangle around the segment a-b with the given
+// thickness as a polygon.
+func lineQuad(a, b image.Point, thickness int) []image.Point {
+	pt := func(p image.Point, sx, sy float64) image.Point {
+		return image.Pt(int(math.Round(float64(p.X)+sx)), int(math.Round(float64(p.Y)+sy)))
+	}
+	h := float64(thickness) / 2
+	if a == b {
+		// Вироджений відрізок малюємо квадратом
+		return []image.Point{pt(a, -h, -h), pt(a, h, -h), pt(a, h, h), pt(a, -h, h)}
+	}
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	length := math.Hypot(dx, dy)
+	// Нормаль до відрізка довжиною в половину товщини
+	nx, ny := -dy/length*h, dx/length*h
+	return []image.Point{pt(a, nx, ny), pt(b, nx, ny), pt(b, -nx, -ny), pt(a, -nx, -ny)}
+}
+
+// shapeColor returns c or DefaultShapeColor if c is nil.
+func shapeColor(c color.Color) color.Color {
+	if c == nil {
+		return DefaultShapeColor
+	}
+	return c
+}
+
+// relPoint converts relative coordinates to pixels.
+func relPoint(s *State, x, y float64) image.Point {
+	return image.Pt(int(x*float64(s.WindowWidth)), int(y*float64(s.WindowHeight)))
+}
+
+// Circle defines the operation for adding a circle. The center is relative
+// to the window, the radius relative to its smaller side.
+type Circle struct {
+	X, Y, R float64
+	Color   color.Color // DefaultShapeColor if nil
+}
+
+func (op Circle) Do(s *State, t screen.Texture) bool {
+	r := int(op.R * float64(min(s.WindowWidth, s.WindowHeight)))
+	s.Shapes = append(s.Shapes, &ShapeOp{ID: s.newID(), Kind: CircleShape, Points: []image.Point{relPoint(s, op.X, op.Y)}, RX: r, RY: r, Color: shapeColor(op.Color), Layer: s.ActiveLayer})
+	log.Printf("Circle.Do: Added circle at (%.2f, %.2f) with radius %d px. State now has %d shapes.", op.X, op.Y, r, len(s.Shapes))
+	return false
+}
+
+// Ellipse defines the operation for adding an axis-aligned ellipse.
+// The horizontal radius is relative to the window width, the vertical one to its height.
+type Ellipse struct {
+	X, Y, RX, RY float64
+	Color        color.Color // DefaultShapeColor if nil
+}
+
+func (op Ellipse) Do(s *State, t screen.Texture) bool {
+	rx, ry := int(op.RX*float64(s.WindowWidth)), int(op.RY*float64(s.WindowHeight))
+	s.Shapes = append(s.Shapes, &ShapeOp{ID: s.newID(), Kind: EllipseShape, Points: []image.Point{relPoint(s, op.X, op.Y)}, RX: rx, RY: ry, Color: shapeColor(op.Color), Layer: s.ActiveLayer})
+	log.Printf("Ellipse.Do: Added ellipse at (%.2f, %.2f) with radii %dx%d px. State now has %d shapes.", op.X, op.Y, rx, ry, len(s.Shapes))
+	return false
+}
+
+// Line defines the operation for adding a segment between two relative points.
+type Line struct {
+	X1, Y1, X2, Y2 float64
+	Thickness      float64     // В пікселях, щонайменше 1
+	Color          color.Color // DefaultShapeColor if nil
+}
+
+func (op Line) Do(s *State, t screen.Texture) bool {
+	thickness := max(int(math.Round(op.Thickness)), 1)
+	s.Shapes = append(s.Shapes, &ShapeOp{
+		ID:        s.newID(),
+		Kind:      LineShape,
+		Points:    []image.Point{relPoint(s, op.X1, op.Y1), relPoint(s, op.X2, op.Y2)},
+		Thickness: thickness,
+		Color:     shapeColor(op.Color),
+		Layer:     s.ActiveLayer,
+	})
+	log.Printf("Line.Do: Added line (%.2f, %.2f)-(%.2f, %.2f) %d px thick. State now has %d shapes.", op.X1, op.Y1, op.X2, op.Y2, thickness, len(s.Shapes))
+	return false
+}
+
+// Polygon defines the operation for adding a polygon with relative vertices.
+type Polygon struct {
+	Points [][2]float64
+	Color  color.Color // DefaultShapeColor if nil
+}
+
+func (op Polygon) Do(s *State, t screen.Texture) bool {
+	if len(op.Points) < 3 {
+		log.Printf("Polygon.Do: Ignoring polygon with %d points", len(op.Points))
+		return false
+	}
+	pts := make([]image.Point, len(op.Points))
+	for i, p := range op.Points {
+		pts[i] = relPoint(s, p[0], p[1])
+	}
+	s.Shapes = append(s.Shapes, &ShapeOp{ID: s.newID(), Kind: PolygonShape, Points: pts, Color: shapeColor(op.Color), Layer: s.ActiveLayer})
+	log.Printf("Polygon.Do: Added polygon with %d points. State now has %d shapes.", len(pts), len(s.Shapes))
+	return false
+}
@@ -0,0 +1,205 @@
+package painter
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Snapshot is a JSON-friendly copy of State. Colors are encoded as
+// "#rrggbb" (or "#rrggbbaa" for translucent colors), figure variants by name.
+type Snapshot struct {
+	Background string           `json:"background"`
+	BgRect     *SnapshotRect    `json:"bgRect"`
+	BgFill     *SnapshotFill    `json:"bgFill,omitempty"`
+	Figures    []SnapshotFigure `json:"figures"`
+	Shapes     []SnapshotShape  `json:"shapes"`
+	Sprites    []SnapshotSprite `json:"sprites,omitempty"`
+	Texts      []SnapshotText   `json:"texts,omitempty"`
+	Layers     []SnapshotLayer  `json:"layers,omitempty"`
+	Active     string           `json:"activeLayer,omitempty"`
+	Groups     []SnapshotGroup  `json:"groups,omitempty"`
+	Offset     SnapshotPoint    `json:"offset"`
+	Width      int              `json:"width"`
+	Height     int              `json:"height"`
+	Antialias  bool             `json:"antialias,omitempty"`
+}
+
+// SnapshotFill is a gradient or pattern background. Size is relative
+// to the smaller side of the window.
+type SnapshotFill struct {
+	Kind  string  `json:"kind"`
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+	Angle float64 `json:"angle,omitempty"`
+	Size  float64 `json:"size,omitempty"`
+}
+
+// SnapshotLayer is a layer of elements. Layers are listed bottom-up.
+type SnapshotLayer struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// SnapshotGroup is a named group of elements with its own move offset.
+type SnapshotGroup struct {
+	Name   string        `json:"name"`
+	Offset SnapshotPoint `json:"offset"`
+	Hidden bool          `json:"hidden,omitempty"`
+}
+
+// SnapshotRect is a rectangle in pixel coordinates.
+type SnapshotRect struct {
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+	X2 int `json:"x2"`
+	Y2 int `json:"y2"`
+
+	Opacity float64 `json:"opacity,omitempty"` // Zero means opaque
+}
+
+// SnapshotPoint is a point in pixel coordinates.
+type SnapshotPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// SnapshotFigure describes a single figure.
+type SnapshotFigure struct {
+	ID      int     `json:"id"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	Variant string  `json:"variant"`
+	Angle   float64 `json:"angle,omitempty"`
+	Size    float64 `json:"size"`
+	Color   string  `json:"color"`
+	Layer   string  `json:"layer,omitempty"`
+	Group   string  `json:"group,omitempty"`
+}
+
+// SnapshotShape describes a primitive shape. Radii are set for circles and
+// ellipses, thickness for lines.
+type SnapshotShape struct {
+	ID        int             `json:"id"`
+	Kind      string          `json:"kind"`
+	Points    []SnapshotPoint `json:"points"`
+	RX        int             `json:"rx,omitempty"`
+	RY        int             `json:"ry,omitempty"`
+	Thickness int             `json:"thickness,omitempty"`
+	Color     string          `json:"color"`
+	Layer     string          `json:"layer,omitempty"`
+	Group     string          `json:"group,omitempty"`
+}
+
+// SnapshotSprite is an uploaded image placed on the canvas. The image
+// itself is stored in SpritesDir, so only its name is saved.
+type SnapshotSprite struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Layer  string `json:"layer,omitempty"`
+	Group  string `json:"group,omitempty"`
+}
+
+// SnapshotText is a text label. Its size is in pixels and is not rescaled.
+type SnapshotText struct {
+	ID    int     `json:"id"`
+	X     int     `json:"x"`
+	Y     int     `json:"y"`
+	Text  string  `json:"text"`
+	Size  float64 `json:"size"`
+	Color string  `json:"color"`
+	Layer string  `json:"layer,omitempty"`
+	Group string  `json:"group,omitempty"`
+}
+
+// Snapshot returns a deep copy of the state in its JSON-friendly form.
+func (s State) Snapshot() Snapshot {
+	snap := Snapshot{
+		Background: ColorHex(s.BgColor),
+		Figures:    make([]SnapshotFigure, 0, len(s.Figures)),
+		Shapes:     make([]SnapshotShape, 0, len(s.Shapes)),
+		Offset:     SnapshotPoint{X: s.MoveOffset.X, Y: s.MoveOffset.Y},
+		Width:      s.WindowWidth,
+		Height:     s.WindowHeight,
+		Antialias:  s.Antialias,
+		Active:     s.ActiveLayer,
+	}
+	if f := s.BgFill; f != nil {
+		snap.BgFill = &SnapshotFill{Kind: f.Kind.String(), From: ColorHex(f.From), To: ColorHex(f.To), Angle: f.Angle, Size: f.Size}
+	}
+	if s.BgRect != nil {
+		snap.BgRect = &SnapshotRect{X1: s.BgRect.X1, Y1: s.BgRect.Y1, X2: s.BgRect.X2, Y2: s.BgRect.Y2, Opacity: s.BgRect.Opacity}
+	}
+	for _, f := range s.Figures {
+		snap.Figures = append(snap.Figures, SnapshotFigure{ID: f.ID, X: f.X, Y: f.Y, Variant: f.Variant.String(), Angle: f.Angle, Size: f.Size, Color: ColorHex(f.Color), Layer: f.Layer, Group: f.Group})
+	}
+	for _, sh := range s.Shapes {
+		pts := make([]SnapshotPoint, len(sh.Points))
+		for i, p := range sh.Points {
+			pts[i] = SnapshotPoint{X: p.X, Y: p.Y}
+		}
+		snap.Shapes = append(snap.Shapes, SnapshotShape{
+			ID: sh.ID, Kind: sh.Kind.String(), Points: pts, RX: sh.RX, RY: sh.RY, Thickness: sh.Thickness, Color: ColorHex(sh.Color), Layer: sh.Layer, Group: sh.Group,
+		})
+	}
+	for _, sp := range s.Sprites {
+		snap.Sprites = append(snap.Sprites, SnapshotSprite{ID: sp.ID, Name: sp.Name, X: sp.X, Y: sp.Y, Width: sp.W, Height: sp.H, Layer: sp.Layer, Group: sp.Group})
+	}
+	for _, tx := range s.Texts {
+		snap.Texts = append(snap.Texts, SnapshotText{ID: tx.ID, X: tx.X, Y: tx.Y, Text: tx.Content, Size: tx.Size, Color: ColorHex(tx.Color), Layer: tx.Layer, Group: tx.Group})
+	}
+	for _, l := range s.Layers {
+		snap.Layers = append(snap.Layers, SnapshotLayer{Name: l.Name, Hidden: l.Hidden})
+	}
+	for _, g := range s.Groups {
+		snap.Groups = append(snap.Groups, SnapshotGroup{Name: g.Name, Offset: SnapshotPoint{X: g.Offset.X, Y: g.Offset.Y}, Hidden: g.Hidden})
+	}
+	return snap
+}
+
+// ColorHex formats c as "#rrggbb", or "#rrggbbaa" if it is not opaque.
+// A nil color is formatted as an empty string.
+func ColorHex(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// ParseColor parses a color in the "#rrggbb" or "#rrggbbaa" form, or in the
+// short "#rgb" or "#rgba" form where every digit is repeated, e.g. "#fff".
+func ParseColor(s string) (color.NRGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && (len(hex) == 3 || len(hex) == 4) {
+		// Коротка форма: кожна цифра повторюється, "#f80" означає "#ff8800"
+		var long strings.Builder
+		for _, r := range hex {
+			long.WriteRune(r)
+			long.WriteRune(r)
+		}
+		hex = long.String()
+	}
+	if !ok || (len(hex) != 6 && len(hex) != 8) {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb or #rrggbbaa", s)
+	}
+	var c color.NRGBA
+	c.A = 0xff
+	var err error
+	if len(hex) == 6 {
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	} else {
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	}
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb or #rrggbbaa", s)
+	}
+	return c, nil
+}
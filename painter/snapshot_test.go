@@ -0,0 +1,38 @@
+package painter_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorHex_ParseColor(t *testing.T) {
+	tests := []struct {
+		hex   string
+		color color.NRGBA
+	}{
+		{"#ffff00", color.NRGBA{R: 0xff, G: 0xff, A: 0xff}},
+		{"#102030", color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}},
+		{"#10203080", color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0x80}},
+	}
+	for _, tt := range tests {
+		c, err := painter.ParseColor(tt.hex)
+		assert.NoError(t, err, tt.hex)
+		assert.Equal(t, tt.color, c)
+		assert.Equal(t, tt.hex, painter.ColorHex(c))
+	}
+
+	// Коротка форма розгортається, але форматується повністю
+	for short, long := range map[string]string{"#fff": "#ffffff", "#f80": "#ff8800", "#0008": "#00000088"} {
+		c, err := painter.ParseColor(short)
+		assert.NoError(t, err, short)
+		assert.Equal(t, long, painter.ColorHex(c))
+	}
+
+	for _, bad := range []string{"", "ffff00", "#ff", "#ggg", "#gggggg", "#1020304050"} {
+		_, err := painter.ParseColor(bad)
+		assert.Error(t, err, bad)
+	}
+}
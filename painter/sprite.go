@@ -0,0 +1,338 @@
+package painter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	_ "image/jpeg" // Завантажені зображення можуть бути у форматі JPEG
+
+	"golang.org/x/exp/shiny/screen"
+	xdraw "golang.org/x/image/draw"
+)
+
+// SpritesDir is the directory where uploaded sprite images are stored as PNG.
+var SpritesDir = "sprites"
+
+// MaxSpriteSide limits the width and height in pixels of uploaded images.
+const MaxSpriteSide = 4096
+
+var (
+	// ErrSpriteNotFound is returned by LoadSprite if there is no image with the name.
+	ErrSpriteNotFound = errors.New("sprite not found")
+	// ErrInvalidSprite wraps errors about invalid sprite names and image files.
+	ErrInvalidSprite = errors.New("invalid sprite")
+)
+
+// Декодовані зображення кешуються за шляхом до файлу, щоб не читати диск
+// при кожному перемальовуванні, а масштабовані - за шляхом і розміром, щоб
+// не масштабувати їх у кожному кадрі. Обидва кеші обмежені обсягом пікселів.
+var (
+	sprites       = imageCache[string]{limit: spriteCacheBytes}
+	scaledSprites = imageCache[scaledKey]{limit: spriteCacheBytes}
+	spritesMu     sync.Mutex
+)
+
+// spriteCacheBytes limits the total size of the pixels kept by each sprite cache.
+const spriteCacheBytes = 256 << 20
+
+// scaledKey identifies an image scaled to the size of a sprite on the screen.
+type scaledKey struct {
+	path string
+	size image.Point
+}
+
+// imageCache keeps the most recently used images while their pixels fit the limit.
+type imageCache[K comparable] struct {
+	limit int // Максимальна сума len(Pix) збережених зображень
+	size  int
+	items map[K]*image.RGBA
+	order []K // Від найдавніше до щойно використаних
+}
+
+func (c *imageCache[K]) get(k K) (*image.RGBA, bool) {
+	img, ok := c.items[k]
+	if ok {
+		c.touch(k)
+	}
+	return img, ok
+}
+
+func (c *imageCache[K]) put(k K, img *image.RGBA) {
+	c.remove(func(key K) bool { return key == k })
+	if c.items == nil {
+		c.items = map[K]*image.RGBA{}
+	}
+	c.items[k] = img
+	c.order = append(c.order, k)
+	c.size += len(img.Pix)
+	for c.size > c.limit && len(c.order) > 1 {
+		oldest := c.order[0]
+		c.remove(func(key K) bool { return key == oldest })
+	}
+}
+
+// remove drops the images whose keys match.
+func (c *imageCache[K]) remove(match func(k K) bool) {
+	c.order = slices.DeleteFunc(c.order, func(k K) bool {
+		if !match(k) {
+			return false
+		}
+		c.size -= len(c.items[k].Pix)
+		delete(c.items, k)
+		return true
+	})
+}
+
+// touch marks the key as the most recently used.
+func (c *imageCache[K]) touch(k K) {
+	i := slices.Index(c.order, k)
+	c.order = append(slices.Delete(c.order, i, i+1), k)
+}
+
+// ValidSpriteName checks that the name can be safely used as a file name.
+func ValidSpriteName(name string) error {
+	if !sceneNameRe.MatchString(name) {
+		return fmt.Errorf("%w name %q: use up to 64 letters, digits, '-' or '_'", ErrInvalidSprite, name)
+	}
+	return nil
+}
+
+func spritePath(name string) (string, error) {
+	if err := ValidSpriteName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(SpritesDir, name+".png"), nil
+}
+
+// SaveSprite decodes a PNG or JPEG image and stores it in SpritesDir under
+// the given name, replacing an image with the same name.
+func SaveSprite(name string, r io.Reader) (image.Image, error) {
+	path, err := spritePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeSprite(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(SpritesDir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(SpritesDir, name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	spritesMu.Lock()
+	sprites.put(path, img)
+	// Масштабовані копії попереднього зображення з цією назвою застаріли
+	scaledSprites.remove(func(k scaledKey) bool { return k.path == path })
+	spritesMu.Unlock()
+	return img, nil
+}
+
+// decodeSprite decodes the image, checking its size before allocating the pixels.
+func decodeSprite(data []byte) (*image.RGBA, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w image: %v", ErrInvalidSprite, err)
+	}
+	if format != "png" && format != "jpeg" {
+		return nil, fmt.Errorf("%w image: unsupported format %s, expected PNG or JPEG", ErrInvalidSprite, format)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxSpriteSide || cfg.Height > MaxSpriteSide {
+		return nil, fmt.Errorf("%w image: size %dx%d, expected at most %dx%d", ErrInvalidSprite, cfg.Width, cfg.Height, MaxSpriteSide, MaxSpriteSide)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w image: %v", ErrInvalidSprite, err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	return img, nil
+}
+
+// LoadSprite returns the image stored under the given name.
+func LoadSprite(name string) (*image.RGBA, error) {
+	path, err := spritePath(name)
+	if err != nil {
+		return nil, err
+	}
+	spritesMu.Lock()
+	defer spritesMu.Unlock()
+	return loadSprite(path, name)
+}
+
+// loadSprite returns the decoded image from the cache or the file. Must be
+// called with spritesMu held.
+func loadSprite(path, name string) (*image.RGBA, error) {
+	if img, ok := sprites.get(path); ok {
+		return img, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSpriteNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeSprite(data)
+	if err != nil {
+		return nil, err
+	}
+	sprites.put(path, img)
+	return img, nil
+}
+
+// ScaledSprite returns the image stored under the given name resized to the
+// given size. Scaled images are cached until the image is uploaded again.
+func ScaledSprite(name string, size image.Point) (*image.RGBA, error) {
+	path, err := spritePath(name)
+	if err != nil {
+		return nil, err
+	}
+	spritesMu.Lock()
+	defer spritesMu.Unlock()
+	key := scaledKey{path: path, size: size}
+	if img, ok := scaledSprites.get(key); ok {
+		return img, nil
+	}
+	img, err := loadSprite(path, name)
+	if err != nil {
+		return nil, err
+	}
+	scaled := scaleImage(img, size)
+	scaledSprites.put(key, scaled)
+	return scaled, nil
+}
+
+// ListSprites returns the names of all stored images in SpritesDir.
+func ListSprites() ([]string, error) {
+	entries, err := os.ReadDir(SpritesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".png"); ok && !e.IsDir() && ValidSpriteName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// scaleImage returns the image resized to the given size. An *image.RGBA of
+// that size, e.g. from ScaledSprite, is returned as is.
+func scaleImage(img image.Image, size image.Point) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect == (image.Rectangle{Max: size}) {
+		return rgba
+	}
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	if size == img.Bounds().Size() {
+		draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	} else {
+		xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	}
+	return dst
+}
+
+// spriteSide clamps a width or height in pixels of a sprite on the screen to
+// [1, MaxSpriteSide], so a scaled image never takes more memory than an upload.
+func spriteSide(n int) int {
+	return min(max(n, 1), MaxSpriteSide)
+}
+
+// SpriteOp represents the state for drawing an uploaded image.
+type SpriteOp struct {
+	ID    int    // Ідентифікатор зі спільної з фігурами нумерації
+	Name  string // Назва зображення в SpritesDir
+	X, Y  int    // Лівий верхній кут у пікселях
+	W, H  int    // Розмір на екрані в пікселях
+	Layer string // Назва шару, "" для DefaultLayer
+	Group string // Назва групи, "" якщо зображення не в групі
+}
+
+// updateSprite replaces the sprite with the given identifier by a modified
+// copy, like updateFigure. It reports whether the sprite exists.
+func (s *State) updateSprite(id int, update func(sp *SpriteOp)) bool {
+	for i, sp := range s.Sprites {
+		if sp.ID == id {
+			c := *sp
+			update(&c)
+			s.Sprites[i] = &c
+			return true
+		}
+	}
+	return false
+}
+
+// draw renders the sprite scaled to its size. Sprites whose image is
+// missing, e.g. in a scene loaded before the upload, are skipped.
+func (sp *SpriteOp) draw(r Renderer, offset image.Point) {
+	img, err := ScaledSprite(sp.Name, image.Pt(sp.W, sp.H))
+	if err != nil {
+		log.Printf("Render: Skipping sprite %d: %v", sp.ID, err)
+		return
+	}
+	r.DrawImage(image.Rect(sp.X, sp.Y, sp.X+sp.W, sp.Y+sp.H).Add(offset), img)
+}
+
+// Sprite defines the operation for placing an uploaded image. The top left
+// corner and the size are relative to the window size; without a size the
+// image is drawn in its own size in pixels.
+type Sprite struct {
+	Name string
+	X, Y float64
+	W, H float64 // Both 0 for the natural size
+}
+
+func (op Sprite) Do(s *State, t screen.Texture) bool {
+	img, err := LoadSprite(op.Name)
+	if err != nil {
+		log.Printf("Sprite.Do: Cannot place image %q: %v", op.Name, err)
+		return false
+	}
+	p := relPoint(s, op.X, op.Y)
+	sp := &SpriteOp{ID: s.newID(), Name: op.Name, X: p.X, Y: p.Y, W: img.Rect.Dx(), H: img.Rect.Dy(), Layer: s.ActiveLayer}
+	if op.W > 0 && op.H > 0 {
+		sp.W = spriteSide(int(op.W * float64(s.WindowWidth)))
+		sp.H = spriteSide(int(op.H * float64(s.WindowHeight)))
+	}
+	s.Sprites = append(s.Sprites, sp)
+	log.Printf("Sprite.Do: Added image %q at (%.2f, %.2f) of size %dx%d. State now has %d sprites.", op.Name, op.X, op.Y, sp.W, sp.H, len(s.Sprites))
+	return false
+}
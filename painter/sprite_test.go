@@ -0,0 +1,123 @@
+package painter_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useSpritesDir points painter.SpritesDir to a temporary directory for the test.
+func useSpritesDir(t *testing.T) {
+	prev := painter.SpritesDir
+	painter.SpritesDir = t.TempDir()
+	t.Cleanup(func() { painter.SpritesDir = prev })
+}
+
+// logoPNG returns a PNG image of the given size filled with the color.
+func logoPNG(t *testing.T, w, h int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSprite_SaveLoad(t *testing.T) {
+	useSpritesDir(t)
+	red := color.NRGBA{R: 0xff, A: 0xff}
+
+	_, err := painter.SaveSprite("logo", bytes.NewReader(logoPNG(t, 4, 2, red)))
+	require.NoError(t, err)
+	img, err := painter.LoadSprite("logo")
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), img.Bounds())
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.RGBAAt(3, 1))
+
+	// JPEG зберігається як PNG під тією ж назвою
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil))
+	_, err = painter.SaveSprite("photo", &buf)
+	require.NoError(t, err)
+
+	names, err := painter.ListSprites()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logo", "photo"}, names)
+
+	_, err = painter.LoadSprite("missing")
+	assert.ErrorIs(t, err, painter.ErrSpriteNotFound)
+	_, err = painter.SaveSprite("bad.name", bytes.NewReader(logoPNG(t, 1, 1, red)))
+	assert.ErrorIs(t, err, painter.ErrInvalidSprite)
+	_, err = painter.SaveSprite("text", strings.NewReader("not an image"))
+	assert.ErrorIs(t, err, painter.ErrInvalidSprite)
+	_, err = painter.SaveSprite("huge", bytes.NewReader(logoPNG(t, painter.MaxSpriteSide+1, 1, red)))
+	assert.ErrorIs(t, err, painter.ErrInvalidSprite)
+}
+
+func TestRender_Sprite(t *testing.T) {
+	useSpritesDir(t)
+	blue := color.NRGBA{B: 0xff, A: 0xff}
+	_, err := painter.SaveSprite("icon", bytes.NewReader(logoPNG(t, 10, 10, blue)))
+	require.NoError(t, err)
+
+	s := painter.State{BgColor: color.White, WindowWidth: 200, WindowHeight: 100}
+	painter.Sprite{Name: "icon", X: 0.1, Y: 0.2}.Do(&s, nil)
+	painter.Sprite{Name: "icon", X: 0.5, Y: 0.5, W: 0.2, H: 0.3}.Do(&s, nil)
+	painter.Sprite{Name: "missing", X: 0.5, Y: 0.5}.Do(&s, nil)
+	require.Len(t, s.Sprites, 2)
+	assert.Equal(t, painter.SpriteOp{ID: 1, Name: "icon", X: 20, Y: 20, W: 10, H: 10}, *s.Sprites[0])
+	assert.Equal(t, painter.SpriteOp{ID: 2, Name: "icon", X: 100, Y: 50, W: 40, H: 30}, *s.Sprites[1])
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+	b := color.RGBA{B: 0xff, A: 0xff}
+	assert.Equal(t, b, img.RGBAAt(20, 20))
+	assert.Equal(t, b, img.RGBAAt(29, 29))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.RGBAAt(30, 30))
+	assert.Equal(t, b, img.RGBAAt(139, 79))
+
+	// Текстура малює ті самі пікселі
+	texture := offscreen.NewTexture(image.Pt(200, 100))
+	painter.Render(painter.TextureRenderer{T: texture}, s)
+	assert.Equal(t, img.Pix, texture.Image().Pix)
+
+	// Зображення зберігаються в сцені за назвою
+	restored := painter.State{WindowWidth: 400, WindowHeight: 200}
+	painter.Restore{Scene: s.Snapshot()}.Do(&restored, nil)
+	require.Len(t, restored.Sprites, 2)
+	assert.Equal(t, painter.SpriteOp{ID: 2, Name: "icon", X: 200, Y: 100, W: 80, H: 60}, *restored.Sprites[1])
+
+	var svg strings.Builder
+	require.NoError(t, painter.WriteSVG(&svg, s))
+	assert.Contains(t, svg.String(), `<image x="100" y="50" width="40" height="30" preserveAspectRatio="none" href="data:image/png;base64,`)
+}
+
+func TestScaledSprite_Reupload(t *testing.T) {
+	useSpritesDir(t)
+	_, err := painter.SaveSprite("icon", bytes.NewReader(logoPNG(t, 4, 4, color.NRGBA{R: 0xff, A: 0xff})))
+	require.NoError(t, err)
+
+	size := image.Pt(8, 6)
+	first, err := painter.ScaledSprite("icon", size)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rectangle{Max: size}, first.Rect)
+	again, err := painter.ScaledSprite("icon", size)
+	require.NoError(t, err)
+	assert.Same(t, first, again, "the scaled image must be cached")
+
+	// Нове завантаження замінює масштабовані копії
+	_, err = painter.SaveSprite("icon", bytes.NewReader(logoPNG(t, 4, 4, color.NRGBA{G: 0xff, A: 0xff})))
+	require.NoError(t, err)
+	updated, err := painter.ScaledSprite("icon", size)
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, updated.RGBAAt(7, 5))
+}
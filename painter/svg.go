@@ -0,0 +1,222 @@
+package painter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// ExportDir is the directory where ExportSVG writes its files.
+var ExportDir = "exports"
+
+// WriteSVG renders the state as an SVG document of the window size.
+// It is drawn by Render, so for opaque colors the image matches the texture exactly.
+func WriteSVG(w io.Writer, s State) error {
+	sr := NewSVGRenderer(w, s.WindowWidth, s.WindowHeight)
+	Render(sr, s)
+	return sr.Close()
+}
+
+// SVGRenderer writes the drawn shapes as SVG elements. Close must be called
+// to finish the document.
+type SVGRenderer struct {
+	w      *bufio.Writer
+	bounds image.Rectangle
+}
+
+// NewSVGRenderer starts an SVG document of the given size on w.
+func NewSVGRenderer(w io.Writer, width, height int) *SVGRenderer {
+	sr := &SVGRenderer{w: bufio.NewWriter(w), bounds: image.Rect(0, 0, width, height)}
+	fmt.Fprintf(sr.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		width, height, width, height)
+	return sr
+}
+
+func (sr *SVGRenderer) Bounds() image.Rectangle { return sr.bounds }
+
+// Clear writes a rect element covering the whole document. Elements written
+// before it stay in the document but are hidden under an opaque color.
+func (sr *SVGRenderer) Clear(c color.Color) { sr.FillRect(sr.bounds, c) }
+
+// FillRect writes a rect element, skipping empty rectangles.
+func (sr *SVGRenderer) FillRect(r image.Rectangle, c color.Color) {
+	if r.Empty() {
+		return
+	}
+	fmt.Fprintf(sr.w, `<rect x="%d" y="%d" width="%d" height="%d"%s/>`+"\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy(), svgFill(c))
+}
+
+// FillBackground writes the gradient or pattern definition and a rect
+// element covering the whole document filled with it.
+func (sr *SVGRenderer) FillBackground(f *BgFillOp) {
+	b := sr.bounds
+	w, h := float64(b.Dx()), float64(b.Dy())
+	fmt.Fprintln(sr.w, "<defs>")
+	switch f.Kind {
+	case LinearGradient:
+		dx, dy := f.direction()
+		half := math.Abs(dx)*w/2 + math.Abs(dy)*h/2
+		cx, cy := w/2, h/2
+		fmt.Fprintf(sr.w, `<linearGradient id="bg" gradientUnits="userSpaceOnUse" x1="%.6g" y1="%.6g" x2="%.6g" y2="%.6g">`+"\n",
+			cx-dx*half, cy-dy*half, cx+dx*half, cy+dy*half)
+		sr.writeStops(f)
+		fmt.Fprintln(sr.w, "</linearGradient>")
+	case RadialGradient:
+		fmt.Fprintf(sr.w, `<radialGradient id="bg" gradientUnits="userSpaceOnUse" cx="%.6g" cy="%.6g" r="%.6g">`+"\n",
+			w/2, h/2, math.Hypot(w/2, h/2))
+		sr.writeStops(f)
+		fmt.Fprintln(sr.w, "</radialGradient>")
+	case Checkerboard:
+		size := f.cellSize(b.Dx(), b.Dy())
+		fmt.Fprintf(sr.w, `<pattern id="bg" patternUnits="userSpaceOnUse" width="%d" height="%d">`+"\n", 2*size, 2*size)
+		fmt.Fprintf(sr.w, `<rect width="%d" height="%d"%s/>`+"\n", 2*size, 2*size, svgFill(f.From))
+		fmt.Fprintf(sr.w, `<rect x="%d" width="%d" height="%d"%s/>`+"\n", size, size, size, svgFill(f.To))
+		fmt.Fprintf(sr.w, `<rect y="%d" width="%d" height="%d"%s/>`+"\n", size, size, size, svgFill(f.To))
+		fmt.Fprintln(sr.w, "</pattern>")
+	case Stripes:
+		size := f.cellSize(b.Dx(), b.Dy())
+		// Вертикальні смуги, повернуті на кут напрямку навколо початку координат
+		fmt.Fprintf(sr.w, `<pattern id="bg" patternUnits="userSpaceOnUse" width="%d" height="%d" patternTransform="rotate(%.6g)">`+"\n",
+			2*size, 2*size, f.Angle)
+		fmt.Fprintf(sr.w, `<rect width="%d" height="%d"%s/>`+"\n", size, 2*size, svgFill(f.From))
+		fmt.Fprintf(sr.w, `<rect x="%d" width="%d" height="%d"%s/>`+"\n", size, size, 2*size, svgFill(f.To))
+		fmt.Fprintln(sr.w, "</pattern>")
+	}
+	fmt.Fprintln(sr.w, "</defs>")
+	fmt.Fprintf(sr.w, `<rect x="%d" y="%d" width="%d" height="%d" fill="url(#bg)"/>`+"\n", b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+}
+
+// writeStops writes the two stops of a gradient.
+func (sr *SVGRenderer) writeStops(f *BgFillOp) {
+	fmt.Fprintf(sr.w, `<stop offset="0"%s/>`+"\n", svgColor("stop-color", f.From))
+	fmt.Fprintf(sr.w, `<stop offset="1"%s/>`+"\n", svgColor("stop-color", f.To))
+}
+
+// FillPolygon writes a polygon element.
+func (sr *SVGRenderer) FillPolygon(pts []image.Point, c color.Color) {
+	if len(pts) < 3 {
+		return
+	}
+	fmt.Fprint(sr.w, `<polygon points="`)
+	for i, p := range pts {
+		if i > 0 {
+			fmt.Fprint(sr.w, " ")
+		}
+		fmt.Fprintf(sr.w, "%d,%d", p.X, p.Y)
+	}
+	fmt.Fprintf(sr.w, `" fill-rule="evenodd"%s/>`+"\n", svgFill(c))
+}
+
+// FillEllipse writes an ellipse element.
+func (sr *SVGRenderer) FillEllipse(center image.Point, rx, ry int, c color.Color) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	fmt.Fprintf(sr.w, `<ellipse cx="%d" cy="%d" rx="%d" ry="%d"%s/>`+"\n", center.X, center.Y, rx, ry, svgFill(c))
+}
+
+// DrawImage writes an image element with the image embedded as PNG.
+func (sr *SVGRenderer) DrawImage(r image.Rectangle, img image.Image) {
+	if r.Empty() {
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Printf("SVGRenderer: Failed to encode image: %v", err)
+		return
+	}
+	fmt.Fprintf(sr.w, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="none" href="data:image/png;base64,%s"/>`+"\n",
+		r.Min.X, r.Min.Y, r.Dx(), r.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// FillText writes a text element. Viewers substitute a sans-serif font
+// if the Go font is not installed, so the text may be slightly wider.
+func (sr *SVGRenderer) FillText(origin image.Point, text string, size float64, c color.Color) {
+	fmt.Fprintf(sr.w, `<text x="%d" y="%d" font-family="Go, sans-serif" font-size="%g"%s>%s</text>`+"\n",
+		origin.X, origin.Y, size, svgFill(c), html.EscapeString(text))
+}
+
+// BeginFigure starts a g element holding the shapes of a figure.
+func (sr *SVGRenderer) BeginFigure(variant FigureVariant) {
+	fmt.Fprintf(sr.w, `<g data-variant="%s">`+"\n", variant)
+}
+
+// EndFigure ends the g element started by BeginFigure.
+func (sr *SVGRenderer) EndFigure() {
+	fmt.Fprintln(sr.w, "</g>")
+}
+
+// Close ends the document and flushes it to the writer.
+func (sr *SVGRenderer) Close() error {
+	fmt.Fprintln(sr.w, "</svg>")
+	return sr.w.Flush()
+}
+
+// svgFill returns the fill attributes for the color.
+func svgFill(c color.Color) string { return svgColor("fill", c) }
+
+// svgColor returns the attribute with the color and, for translucent colors,
+// the matching opacity attribute, e.g. fill and fill-opacity.
+func svgColor(attr string, c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	s := fmt.Sprintf(` %s="#%02x%02x%02x"`, attr, n.R, n.G, n.B)
+	if n.A != 0xff {
+		s += fmt.Sprintf(` %s-opacity="%.3g"`, strings.TrimSuffix(attr, "-color"), float64(n.A)/0xff)
+	}
+	return s
+}
+
+// ExportSVG defines the operation for writing the current state as SVG,
+// either to Writer or, if it is nil, to the file Name.svg in ExportDir.
+type ExportSVG struct {
+	Name   string
+	Writer io.Writer
+	Result chan<- error // Optional buffered channel receiving the outcome of the export
+}
+
+func (op ExportSVG) Do(s *State, t screen.Texture) bool {
+	err := op.write(*s)
+	if err != nil {
+		log.Printf("ExportSVG.Do: Failed to export SVG: %v", err)
+	} else {
+		log.Printf("ExportSVG.Do: Exported %d figures", len(s.Figures))
+	}
+	if op.Result != nil {
+		op.Result <- err
+	}
+	return false
+}
+
+func (op ExportSVG) write(s State) error {
+	if op.Writer != nil {
+		return WriteSVG(op.Writer, s)
+	}
+	if err := ValidSceneName(op.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ExportDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(ExportDir, op.Name+".svg"))
+	if err != nil {
+		return err
+	}
+	if err := WriteSVG(f, s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
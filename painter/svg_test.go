@@ -0,0 +1,73 @@
+package painter_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSVG(t *testing.T) {
+	state := painter.State{
+		BgColor: color.White,
+		BgRect:  &painter.BgRectOp{X1: 10, Y1: 20, X2: 110, Y2: 220},
+		Figures: []*painter.FigureOp{
+			{X: 400, Y: 400, Variant: painter.T180, Color: color.NRGBA{R: 0xff, G: 0xff, A: 0xff}},
+			{X: 100, Y: 100, Variant: painter.Cross, Color: color.NRGBA{B: 0xff, A: 0x80}},
+		},
+		MoveOffset:   image.Pt(10, 0),
+		WindowWidth:  800,
+		WindowHeight: 800,
+	}
+	var buf bytes.Buffer
+	require.NoError(t, painter.WriteSVG(&buf, state))
+	svg := buf.String()
+
+	lines := strings.Split(strings.TrimSpace(svg), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], `<svg xmlns="http://www.w3.org/2000/svg" width="800" height="800"`), lines[0])
+	assert.Equal(t, "</svg>", lines[len(lines)-1])
+	// Drawing order: background, BgRect with its 4 border lines, then figures.
+	assert.Equal(t, `<rect x="0" y="0" width="800" height="800" fill="#ffffff"/>`, lines[1])
+	assert.Equal(t, `<rect x="10" y="20" width="100" height="200" fill="#000000"/>`, lines[2])
+	assert.Equal(t, `<rect x="10" y="20" width="100" height="1" fill="#00ff00"/>`, lines[3])
+	assert.Equal(t, `<g data-variant="T180">`, lines[7])
+	// T180 at (410, 400) with size 240: bar at the bottom, stem above it.
+	assert.Equal(t, `<rect x="290" y="440" width="240" height="80" fill="#ffff00"/>`, lines[8])
+	assert.Equal(t, `<rect x="370" y="280" width="80" height="160" fill="#ffff00"/>`, lines[9])
+	assert.Contains(t, svg, `fill="#0000ff" fill-opacity="0.502"`)
+	assert.Equal(t, 2, strings.Count(svg, "<g "))
+
+	state.Shapes = []*painter.ShapeOp{
+		{Kind: painter.CircleShape, Points: []image.Point{{50, 60}}, RX: 10, RY: 10, Color: color.Black},
+		{Kind: painter.PolygonShape, Points: []image.Point{{0, 0}, {10, 0}, {0, 10}}, Color: color.Black},
+	}
+	buf.Reset()
+	require.NoError(t, painter.WriteSVG(&buf, state))
+	assert.Contains(t, buf.String(), `<ellipse cx="60" cy="60" rx="10" ry="10" fill="#000000"/>`)
+	assert.Contains(t, buf.String(), `<polygon points="10,0 20,0 10,10" fill-rule="evenodd" fill="#000000"/>`)
+}
+
+func TestExportSVG_File(t *testing.T) {
+	prev := painter.ExportDir
+	painter.ExportDir = t.TempDir()
+	defer func() { painter.ExportDir = prev }()
+
+	result := make(chan error, 1)
+	state := painter.State{BgColor: color.White, WindowWidth: 100, WindowHeight: 100}
+	painter.ExportSVG{Name: "report", Result: result}.Do(&state, nil)
+	require.NoError(t, <-result)
+
+	data, err := os.ReadFile(filepath.Join(painter.ExportDir, "report.svg"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `fill="#ffffff"`)
+
+	painter.ExportSVG{Name: "../report", Result: result}.Do(&state, nil)
+	assert.ErrorIs(t, <-result, painter.ErrInvalidScene)
+}
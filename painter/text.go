@@ -0,0 +1,162 @@
+package painter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/exp/shiny/screen"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultTextSize is the font size in pixels of labels created without one.
+const DefaultTextSize = 16
+
+// Limits of text labels: the font size in pixels and the number of characters.
+const (
+	MinTextSize   = 4
+	MaxTextSize   = 400
+	MaxTextLength = 1000
+)
+
+// DefaultTextColor is used for labels created without a color.
+var DefaultTextColor color.Color = color.NRGBA{A: 0xff}
+
+// TextOp represents the state for drawing a text label.
+type TextOp struct {
+	ID      int     // Ідентифікатор зі спільної з фігурами нумерації
+	X, Y    int     // Лівий верхній кут рядка тексту в пікселях
+	Content string  // Текст мітки в один рядок
+	Size    float64 // Розмір шрифту в пікселях
+	Color   color.Color
+	Layer   string // Назва шару, "" для DefaultLayer
+	Group   string // Назва групи, "" якщо мітка не в групі
+}
+
+// draw renders the label with its baseline below the top left corner.
+func (tx *TextOp) draw(r Renderer, offset image.Point) {
+	ascent := textAscent(tx.Size)
+	r.FillText(image.Pt(tx.X, tx.Y+ascent).Add(offset), tx.Content, tx.Size, tx.Color)
+}
+
+// ValidText checks that a label is not empty and fits the text limits.
+func ValidText(content string, size float64) error {
+	if content == "" {
+		return fmt.Errorf("empty text")
+	}
+	if n := utf8.RuneCountInString(content); n > MaxTextLength {
+		return fmt.Errorf("text of %d characters, expected at most %d", n, MaxTextLength)
+	}
+	if size < MinTextSize || size > MaxTextSize {
+		return fmt.Errorf("text size %g out of range [%d, %d]", size, MinTextSize, MaxTextSize)
+	}
+	return nil
+}
+
+// updateText replaces the label with the given identifier by a modified
+// copy, like updateFigure. It reports whether the label exists.
+func (s *State) updateText(id int, update func(tx *TextOp)) bool {
+	for i, tx := range s.Texts {
+		if tx.ID == id {
+			c := *tx
+			update(&c)
+			s.Texts[i] = &c
+			return true
+		}
+	}
+	return false
+}
+
+// Шрифт Go Regular вбудований у golang.org/x/image, тож зовнішні файли не потрібні.
+// Грані opentype не можна використовувати з кількох горутин одночасно,
+// тому доступ до них захищений м'ютексом.
+var (
+	textFont     *opentype.Font
+	textFaces    = map[float64]font.Face{} // Грані за розміром у цілих пікселях
+	textFacesMu  sync.Mutex
+	textFontOnce sync.Once
+)
+
+// textFace returns the face of the given pixel size. The size is rounded to
+// whole pixels within the text limits, so the cache holds at most one face per
+// pixel size. Must be called with textFacesMu held.
+func textFace(size float64) font.Face {
+	size = math.Round(size)
+	if size < MinTextSize {
+		size = MinTextSize
+	} else if size > MaxTextSize {
+		size = MaxTextSize
+	}
+	textFontOnce.Do(func() {
+		var err error
+		if textFont, err = opentype.Parse(goregular.TTF); err != nil {
+			log.Fatalf("text: Failed to parse the embedded font: %v", err)
+		}
+	})
+	if face, ok := textFaces[size]; ok {
+		return face
+	}
+	// При 72 DPI розмір у пунктах дорівнює розміру в пікселях
+	face, err := opentype.NewFace(textFont, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		log.Fatalf("text: Failed to create a face of size %g: %v", size, err)
+	}
+	textFaces[size] = face
+	return face
+}
+
+// textAscent returns the distance in pixels from the top of a line of text
+// of the given size to its baseline.
+func textAscent(size float64) int {
+	textFacesMu.Lock()
+	defer textFacesMu.Unlock()
+	return textFace(size).Metrics().Ascent.Ceil()
+}
+
+// textMask rasterises the text into an alpha mask whose coordinates are
+// relative to the left end of the baseline.
+func textMask(text string, size float64) *image.Alpha {
+	textFacesMu.Lock()
+	defer textFacesMu.Unlock()
+	face := textFace(size)
+	bounds, _ := font.BoundString(face, text)
+	mask := image.NewAlpha(image.Rect(bounds.Min.X.Floor(), bounds.Min.Y.Floor(), bounds.Max.X.Ceil(), bounds.Max.Y.Ceil()))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.Point26_6{}}
+	d.DrawString(text)
+	return mask
+}
+
+// Text defines the operation for adding a text label. The top left corner
+// is relative to the window size, Size is in pixels.
+type Text struct {
+	X, Y    float64
+	Content string
+	Size    float64     // DefaultTextSize if 0
+	Color   color.Color // DefaultTextColor if nil
+}
+
+func (op Text) Do(s *State, t screen.Texture) bool {
+	size := op.Size
+	if size == 0 {
+		size = DefaultTextSize
+	}
+	if err := ValidText(op.Content, size); err != nil {
+		log.Printf("Text.Do: Ignoring text: %v", err)
+		return false
+	}
+	p := relPoint(s, op.X, op.Y)
+	tx := &TextOp{ID: s.newID(), X: p.X, Y: p.Y, Content: op.Content, Size: size, Color: op.Color, Layer: s.ActiveLayer}
+	if tx.Color == nil {
+		tx.Color = DefaultTextColor
+	}
+	s.Texts = append(s.Texts, tx)
+	log.Printf("Text.Do: Added text %q at (%.2f, %.2f). State now has %d texts.", op.Content, op.X, op.Y, len(s.Texts))
+	return false
+}
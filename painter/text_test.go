@@ -0,0 +1,104 @@
+package painter_test
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// inkBounds returns the bounds of the pixels that differ from white.
+func inkBounds(img *image.RGBA) image.Rectangle {
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	var ink image.Rectangle
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.RGBAAt(x, y) != white {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+func TestRender_Text(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 200, WindowHeight: 100}
+	painter.Text{X: 0.1, Y: 0.2, Content: "Hello"}.Do(&s, nil)
+	require.Len(t, s.Texts, 1)
+	assert.Equal(t, 16.0, s.Texts[0].Size)
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+	ink := inkBounds(img)
+	// Текст починається в лівому верхньому куті (20, 20) і має висоту в межах рядка
+	assert.InDelta(t, 21, ink.Min.X, 2)
+	assert.InDelta(t, 22, ink.Min.Y, 3)
+	assert.Less(t, ink.Max.Y, 20+16+2)
+	assert.Greater(t, ink.Dx(), 30)
+
+	// Текстура малює ті самі пікселі, з точністю до округлення альфи
+	texture := offscreen.NewTexture(image.Pt(200, 100))
+	painter.Render(painter.TextureRenderer{T: texture}, s)
+	got := texture.Image()
+	for i := range img.Pix {
+		assert.InDelta(t, img.Pix[i], got.Pix[i], 2, "byte %d", i)
+	}
+
+	// Мітки зсуваються разом із фігурами
+	painter.Move{X: 0.5, Y: 0}.Do(&s, nil)
+	painter.Render(painter.ImageRenderer{Img: img}, s)
+	assert.Equal(t, ink.Add(image.Pt(100, 0)), inkBounds(img))
+}
+
+func TestText_SnapshotAndSVG(t *testing.T) {
+	s := painter.State{BgColor: color.White, WindowWidth: 200, WindowHeight: 100}
+	painter.Figure{X: 0.5, Y: 0.5}.Do(&s, nil)
+	painter.Text{X: 0.5, Y: 0.5, Content: "a < b", Size: 24, Color: color.NRGBA{R: 0xff, A: 0xff}}.Do(&s, nil)
+	painter.Opacity{ID: 2, Value: 0.5}.Do(&s, nil)
+
+	restored := painter.State{WindowWidth: 400, WindowHeight: 200}
+	painter.Restore{Scene: s.Snapshot()}.Do(&restored, nil)
+	require.Len(t, restored.Texts, 1)
+	assert.Equal(t, painter.TextOp{ID: 2, X: 200, Y: 100, Content: "a < b", Size: 24, Color: color.NRGBA{R: 0xff, A: 0x80}}, *restored.Texts[0])
+	assert.Equal(t, 2, restored.LastID)
+
+	var b strings.Builder
+	require.NoError(t, painter.WriteSVG(&b, s))
+	assert.Regexp(t, `<text x="100" y="\d+" font-family="Go, sans-serif" font-size="24" fill="#ff0000" fill-opacity="0.502">a &lt; b</text>`, b.String())
+}
+
+// bufferScreen counts the buffers created by the loop for rendering.
+type bufferScreen struct {
+	offscreen.Screen
+	mu      sync.Mutex
+	buffers int
+}
+
+func (s *bufferScreen) NewBuffer(size image.Point) (screen.Buffer, error) {
+	s.mu.Lock()
+	s.buffers++
+	s.mu.Unlock()
+	return s.Screen.NewBuffer(size)
+}
+
+func TestLoop_TextIsRenderedBuffered(t *testing.T) {
+	scr := &bufferScreen{}
+	loop := painter.NewLoop(newMockReceiver(), 200, 100)
+	loop.Start(scr)
+	defer loop.Stop()
+
+	loop.Post(painter.OperationList{painter.Text{X: 0.1, Y: 0.2, Content: "Hello"}, painter.UpdateOp{}})
+	<-loop.Idle()
+
+	// Текст повільно малювати на текстурі, тому кадр рендериться в буфер
+	scr.mu.Lock()
+	defer scr.mu.Unlock()
+	assert.Equal(t, 1, scr.buffers)
+}